@@ -30,6 +30,20 @@ type templateConfig struct {
 	Lang string `toml:"language"`
 	File string `toml:"file"`
 	Run  string `toml:"run"`
+
+	// Build is run once before the test cases, e.g. to compile the program.
+	Build string `toml:"build,omitempty"`
+	// Shell runs the commands through the system shell.
+	Shell bool `toml:"shell,omitempty"`
+	// Debugger is the command launched by dbg, e.g. "gdb {binary}".
+	Debugger string `toml:"debugger,omitempty"`
+
+	// Timeout is the time limit of each test case in seconds.
+	Timeout number `toml:"timeout,omitzero"`
+	// MemoryLimit is the memory limit of each test case in megabytes.
+	MemoryLimit number `toml:"memoryLimit,omitzero"`
+
+	checkerConfig
 }
 
 // addCmd represents the add command
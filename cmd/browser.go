@@ -0,0 +1,135 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/sclevine/agouti"
+)
+
+// browserOptions selects how the browser session is started. The empty
+// fields fall back to config.toml.
+type browserOptions struct {
+	headless        bool
+	debuggerAddress string
+	remoteURL       string
+}
+
+// newPage opens a page, either through a local WebDriver (ChromeDriver or
+// geckodriver) or a remote WebDriver server. The returned function ends the
+// session.
+func newPage(opts browserOptions) (*agouti.Page, func(), error) {
+	config, err := getConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	setting := config.Setting
+
+	browser := strings.ToLower(setting.Browser)
+	remoteURL := opts.remoteURL
+	if strings.HasPrefix(browser, "http://") || strings.HasPrefix(browser, "https://") {
+		if remoteURL == "" {
+			remoteURL = setting.Browser
+		}
+		browser = ""
+	}
+	headless := opts.headless || setting.Headless
+
+	var capabilities agouti.Capabilities
+	var driver *agouti.WebDriver
+	switch browser {
+	case "", "chrome", "chromium":
+		binary := setting.ChromeBinary
+		// The binary of a remote node is not on this machine.
+		if binary == "" && browser == "chromium" && remoteURL == "" {
+			if binary, err = findChromium(); err != nil {
+				return nil, nil, err
+			}
+		}
+		// Selenium Grid needs browserName to pick a node, also when only a
+		// WebDriver URL is given.
+		capabilities = agouti.NewCapabilities().Browser("chrome")
+		// Recent versions of ChromeDriver only read the prefixed key.
+		capabilities["goog:chromeOptions"] = chromeOptions(setting, opts, binary, headless)
+		driver = agouti.ChromeDriver()
+	case "firefox", "geckodriver":
+		firefoxOptions := map[string]interface{}{}
+		if headless {
+			firefoxOptions["args"] = []string{"-headless"}
+		}
+		capabilities = agouti.NewCapabilities().Browser("firefox")
+		capabilities["moz:firefoxOptions"] = firefoxOptions
+		driver = agouti.GeckoDriver()
+	default:
+		return nil, nil, fmt.Errorf("  [ERR] Unknown browser: %v (chrome, chromium, firefox or a WebDriver URL)", setting.Browser)
+	}
+
+	if remoteURL != "" {
+		page, err := agouti.NewPage(remoteURL, agouti.Desired(capabilities))
+		if err != nil {
+			return nil, nil, err
+		}
+		return page, func() { page.Destroy() }, nil
+	}
+
+	if err := driver.Start(); err != nil {
+		return nil, nil, err
+	}
+	page, err := driver.NewPage(agouti.Desired(capabilities))
+	if err != nil {
+		driver.Stop()
+		return nil, nil, err
+	}
+	return page, func() { driver.Stop() }, nil
+}
+
+func chromeOptions(setting SettingConfig, opts browserOptions, binary string, headless bool) map[string]interface{} {
+	options := map[string]interface{}{}
+	debuggerAddress := opts.debuggerAddress
+	if debuggerAddress == "" {
+		debuggerAddress = setting.DebuggerAddress
+	}
+	if debuggerAddress != "" {
+		// An attached Chrome keeps its own arguments and profile.
+		options["debuggerAddress"] = debuggerAddress
+		return options
+	}
+	args := append([]string{}, setting.ChromeArgs...)
+	if headless {
+		args = append(args, "--headless", "--disable-gpu", "--window-size=1280,1024")
+	}
+	if len(args) > 0 {
+		options["args"] = args
+	}
+	if binary != "" {
+		options["binary"] = binary
+	}
+	return options
+}
+
+// findChromium looks for the Chromium executable, whose name differs
+// between distributions.
+func findChromium() (string, error) {
+	for _, name := range []string{"chromium", "chromium-browser"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("  [ERR] Could not find Chromium. Set chromeBinary in config.toml")
+}
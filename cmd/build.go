@@ -0,0 +1,115 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mgutz/ansi"
+)
+
+const (
+	ceColor = "magenta"
+
+	// buildStampFile records the hash of the last successful build.
+	buildStampFile = ".pz-build"
+
+	// buildTimeout stops a hung compiler.
+	buildTimeout = 5 * time.Minute
+)
+
+// buildProgram runs the build command of the template once before the
+// test cases. It is skipped when neither the source nor the command has
+// changed since the last successful build and the {binary} it produces
+// still exists, and returns false when the compilation failed.
+func buildProgram(tempConfig templateConfig, cmdCtx commandContext, w io.Writer) (bool, error) {
+	if tempConfig.Build == "" {
+		return true, nil
+	}
+	cmdStrings := cmdCtx.expand(tempConfig.Build, false)
+	hash, err := buildHash(cmdCtx.vars["file"], cmdStrings)
+	if err != nil {
+		return false, err
+	}
+	stampPath := filepath.Join(cmdCtx.dir, buildStampFile)
+	if stamp, err := ioutil.ReadFile(stampPath); err == nil && string(stamp) == hash && buildOutputExists(tempConfig, cmdCtx) {
+		fmt.Fprintln(w, "  Build: up to date")
+		return true, nil
+	}
+
+	fmt.Fprintf(w, "  Build: %v\n", cmdStrings)
+	cmd, err := cmdCtx.command(tempConfig.Build)
+	if err != nil {
+		return false, err
+	}
+	result, err := runCommand(cmd, false, buildTimeout)
+	if err != nil {
+		return false, err
+	}
+	if result.timedOut || result.exitCode != 0 || result.signal != "" {
+		var cause string
+		if result.timedOut {
+			cause = fmt.Sprintf("time limit:%vs", buildTimeout.Seconds())
+		} else if result.signal != "" {
+			cause = fmt.Sprintf("signal:%v", result.signal)
+		} else {
+			cause = fmt.Sprintf("exit code:%v", result.exitCode)
+		}
+		fmt.Fprintln(w, ansi.Color(fmt.Sprintf("    [CE] Compilation error. (%v)", cause), ceColor))
+		for _, out := range []string{result.stdout, result.stderr} {
+			for _, l := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+				if l != "" {
+					fmt.Fprintf(w, "    >> %v\n", ansi.Color(l, stderrColor))
+				}
+			}
+		}
+		return false, nil
+	}
+	fmt.Fprintf(w, "    [Build] Finished. (time:%vs)\n", result.elapsed.Seconds())
+	if err = writeFile(stampPath, hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// buildOutputExists reports whether the binary of the last build is still
+// there. Templates which do not use {binary} cannot be checked, and rely on
+// the stamp alone.
+func buildOutputExists(tempConfig templateConfig, cmdCtx commandContext) bool {
+	if !strings.Contains(tempConfig.Build, "{binary}") && !strings.Contains(tempConfig.Run, "{binary}") {
+		return true
+	}
+	return Exists(cmdCtx.vars["binary"])
+}
+
+func buildHash(programPath, cmdStrings string) (string, error) {
+	src, err := ioutil.ReadFile(programPath)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(cmdStrings))
+	h.Write([]byte{0})
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
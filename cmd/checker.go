@@ -0,0 +1,238 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	checkerExact  = "exact"
+	checkerToken  = "token"
+	checkerFloat  = "float"
+	checkerCustom = "custom"
+
+	// defaultFloatError is the tolerance of the float checker when
+	// neither absError nor relError is set.
+	defaultFloatError = 1e-6
+)
+
+// checkerConfig selects how the output of a test case is judged. It is
+// shared by template.toml and problem.toml, and the latter wins.
+type checkerConfig struct {
+	Checker  string `toml:"checker,omitempty"`
+	AbsError number `toml:"absError,omitzero"`
+	RelError number `toml:"relError,omitzero"`
+	Judge    string `toml:"judge,omitempty"`
+}
+
+// problemConfig is read from problem.toml in the problem directory.
+type problemConfig struct {
+	checkerConfig
+
+	// Generator prints a random input for the seed given as its last
+	// argument, and Reference is a brute-force solution. Both are used by stress.
+	Generator string `toml:"generator,omitempty"`
+	Reference string `toml:"reference,omitempty"`
+
+	// Interactor makes the problem interactive. It talks with the solution
+	// through its stdin/stdout and receives the paths of the input and the
+	// expected output as arguments.
+	Interactor string `toml:"interactor,omitempty"`
+}
+
+// checkCase is passed to a checker for each test case.
+type checkCase struct {
+	inputPath  string
+	outputPath string
+	expected   string
+	actual     string
+}
+
+// checker returns whether the case is accepted and an optional message.
+type checker func(c checkCase) (bool, string, error)
+
+func getProblemConfig(quesDir string) (problemConfig, error) {
+	var probConfig problemConfig
+	p := filepath.Join(quesDir, "problem.toml")
+	if !Exists(p) {
+		return probConfig, nil
+	}
+	unknown, err := decodeFile(p, &probConfig)
+	if err != nil {
+		return problemConfig{}, err
+	}
+	warnUnknownKeys(p, unknown)
+	return probConfig, nil
+}
+
+// mergeChecker overrides the template checker settings with the problem
+// ones field by field. A problem which only sets a tolerance is judged by
+// the float checker.
+func mergeChecker(temp, prob checkerConfig) checkerConfig {
+	merged := temp
+	if prob.Checker != "" {
+		merged.Checker = prob.Checker
+	} else if prob.AbsError != 0 || prob.RelError != 0 {
+		merged.Checker = checkerFloat
+	}
+	if prob.AbsError != 0 {
+		merged.AbsError = prob.AbsError
+	}
+	if prob.RelError != 0 {
+		merged.RelError = prob.RelError
+	}
+	if prob.Judge != "" {
+		merged.Judge = prob.Judge
+	}
+	return merged
+}
+
+func newChecker(conf checkerConfig, cmdCtx commandContext) (checker, error) {
+	switch conf.Checker {
+	case "", checkerExact:
+		return checkExact, nil
+	case checkerToken:
+		return checkToken, nil
+	case checkerFloat:
+		absError, relError := float64(conf.AbsError), float64(conf.RelError)
+		if absError == 0 && relError == 0 {
+			absError, relError = defaultFloatError, defaultFloatError
+		}
+		return func(c checkCase) (bool, string, error) {
+			return checkFloat(c, absError, relError)
+		}, nil
+	case checkerCustom:
+		if conf.Judge == "" {
+			return nil, fmt.Errorf("  [ERR] Set a judge command to use the custom checker.")
+		}
+		return func(c checkCase) (bool, string, error) {
+			return checkCustom(c, conf.Judge, cmdCtx)
+		}, nil
+	}
+	return nil, fmt.Errorf("  [ERR] Unknown checker: %v", conf.Checker)
+}
+
+// normalizeLines trims the surrounding blank lines and trailing spaces of
+// each line, so that an extra newline or CRLF does not fail the case.
+func normalizeLines(s string) []string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return lines
+}
+
+func checkExact(c checkCase) (bool, string, error) {
+	expected := normalizeLines(c.expected)
+	actual := normalizeLines(c.actual)
+	if len(expected) != len(actual) {
+		return false, "", nil
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return false, "", nil
+		}
+	}
+	return true, "", nil
+}
+
+func checkToken(c checkCase) (bool, string, error) {
+	expected := strings.Fields(c.expected)
+	actual := strings.Fields(c.actual)
+	if len(expected) != len(actual) {
+		return false, fmt.Sprintf("expected %v tokens, got %v", len(expected), len(actual)), nil
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return false, fmt.Sprintf("token %v: expected %q, got %q", i+1, expected[i], actual[i]), nil
+		}
+	}
+	return true, "", nil
+}
+
+// checkFloat compares tokens as numbers when both sides parse as floats,
+// accepting an absolute or relative error within the tolerance.
+func checkFloat(c checkCase, absError, relError float64) (bool, string, error) {
+	expected := strings.Fields(c.expected)
+	actual := strings.Fields(c.actual)
+	if len(expected) != len(actual) {
+		return false, fmt.Sprintf("expected %v tokens, got %v", len(expected), len(actual)), nil
+	}
+	for i := range expected {
+		e, errE := strconv.ParseFloat(expected[i], 64)
+		a, errA := strconv.ParseFloat(actual[i], 64)
+		if errE != nil || errA != nil {
+			if expected[i] != actual[i] {
+				return false, fmt.Sprintf("token %v: expected %q, got %q", i+1, expected[i], actual[i]), nil
+			}
+			continue
+		}
+		// NaN and infinities only match themselves.
+		if math.IsNaN(e) || math.IsNaN(a) || math.IsInf(e, 0) || math.IsInf(a, 0) {
+			if e == a || math.IsNaN(e) && math.IsNaN(a) {
+				continue
+			}
+			return false, fmt.Sprintf("token %v: expected %v, got %v", i+1, expected[i], actual[i]), nil
+		}
+		diff := math.Abs(e - a)
+		if diff <= absError || diff <= relError*math.Abs(e) {
+			continue
+		}
+		return false, fmt.Sprintf("token %v: expected %v, got %v (error %g)", i+1, expected[i], actual[i], diff), nil
+	}
+	return true, "", nil
+}
+
+// checkCustom runs the judge command with the paths of the input, the
+// expected output and the actual output. Exit code 0 means accepted.
+func checkCustom(c checkCase, judge string, cmdCtx commandContext) (bool, string, error) {
+	actualFile, err := ioutil.TempFile("", "pz-actual-*.txt")
+	if err != nil {
+		return false, "", err
+	}
+	defer os.Remove(actualFile.Name())
+	if _, err = actualFile.WriteString(c.actual); err != nil {
+		actualFile.Close()
+		return false, "", err
+	}
+	if err = actualFile.Close(); err != nil {
+		return false, "", err
+	}
+
+	cmd, err := cmdCtx.command(judge, c.inputPath, c.outputPath, actualFile.Name())
+	if err != nil {
+		return false, "", err
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err = cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return false, strings.TrimSpace(out.String()), nil
+		}
+		return false, "", err
+	}
+	return true, strings.TrimSpace(out.String()), nil
+}
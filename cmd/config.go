@@ -28,6 +28,32 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// The environment variables override config.toml, and the command line
+// flags override both.
+const (
+	envConfig          = "PZ_CONFIG"
+	envEmail           = "PZ_EMAIL"
+	envPassword        = "PZ_PASSWORD"
+	envDefaultTemplate = "PZ_DEFAULT_TEMPLATE"
+	envWorkspace       = "PZ_WORKSPACE"
+)
+
+// number is a float setting which also accepts an integer, since toml
+// rejects "timeout = 2" for a float64 field.
+type number float64
+
+func (n *number) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case int64:
+		*n = number(v)
+	case float64:
+		*n = number(v)
+	default:
+		return fmt.Errorf("toml: cannot load TOML value of type %T into a number", v)
+	}
+	return nil
+}
+
 type Config struct {
 	User    UserConfig
 	Setting SettingConfig
@@ -35,61 +61,69 @@ type Config struct {
 
 type UserConfig struct {
 	Email string `toml:"email"`
-	Pass  string `toml:"password"`
+	// Pass is a plaintext password of older versions. It is moved to a
+	// secret store on first use.
+	Pass string `toml:"password,omitempty"`
+	// PasswordStore is where the password is kept: "keyring" or "file".
+	PasswordStore string `toml:"passwordStore,omitempty"`
+	// PasswordEnv names an environment variable holding the password.
+	PasswordEnv string `toml:"passwordEnv,omitempty"`
+	// PasswordCommand prints the password, e.g. "pass show paiza".
+	PasswordCommand string `toml:"passwordCommand,omitempty"`
 }
 
 type SettingConfig struct {
 	DefalutTemp string `toml:"defaultTemplate"`
-	// AutoSubmit  bool   `toml:"autoSubmit"`
+	Timeout     number `toml:"timeout,omitzero"`
+	MemoryLimit number `toml:"memoryLimit,omitzero"`
+	Jobs        int    `toml:"jobs,omitzero"`
+	DiffStyle   string `toml:"diffStyle,omitempty"`
+	DiffLines   int    `toml:"diffLines,omitzero"`
+	// Workspace is the directory where get creates the question
+	// directories. The current directory is used when it is empty.
+	Workspace string `toml:"workspace,omitempty"`
+
+	// Browser selects the browser: "chrome" (default), "chromium",
+	// "firefox" or the URL of a remote WebDriver server.
+	Browser string `toml:"browser,omitempty"`
+	// Headless runs the browser without a window.
+	Headless bool `toml:"headless,omitempty"`
+	// ChromeArgs are passed to Chrome on start, e.g. ["--user-data-dir=..."].
+	ChromeArgs []string `toml:"chromeArgs,omitempty"`
+	// ChromeBinary is the path of the Chrome executable.
+	ChromeBinary string `toml:"chromeBinary,omitempty"`
+	// DebuggerAddress attaches to a Chrome started with
+	// --remote-debugging-port, e.g. "127.0.0.1:9222".
+	DebuggerAddress string `toml:"debuggerAddress,omitempty"`
 }
 
 // configCmd represents the config command
 var configCmd = &cobra.Command{
-	Use:   "config",
+	Use:   "config [key value]",
 	Short: "Check and change the settings",
+	Long: `Check and change the settings.
+Without arguments it lists the settings like config list.
+"pz config key value" is the same as "pz config set key value".`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		err := printConfig()
-		if err != nil {
+		if len(args) == 2 {
+			if err := setConfigValue(args[0], args[1]); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+		if err := listConfig(); err != nil {
 			log.Fatalln(err)
 		}
 	},
 }
 
-func printConfig() error {
-	config, err := getConfig()
-	if err != nil {
-		return err
-	}
-	configString, err := encodeConfig(config)
-	if err != nil {
-		return err
-	}
-	fmt.Println(configString)
-	return nil
-}
-
-func updateConfig(key, value string) error {
-	config, err := getConfig()
-	if err != nil {
-		return err
-	}
-	if key == "email" {
-		config.User.Email = value
-	} else if key == "password" {
-		config.User.Pass = value
-	} else if key == "defaultTemplate" {
-		config.Setting.DefalutTemp = value
-		// } else if key == "autoSubmit" {
-		// if value == "true" {
-		// config.Setting.AutoSubmit = true
-		// } else if value == "false" {
-		// config.Setting.AutoSubmit = false
-		// } else {
-		// return fmt.Errorf("Unknown value: %v", value)
-		// }
-	} else {
-		return fmt.Errorf("Unknown key: %v", key)
-	}
+func writeConfig(config Config) error {
 	configString, err := encodeConfig(config)
 	if err != nil {
 		return err
@@ -104,7 +138,21 @@ func updateConfig(key, value string) error {
 	return nil
 }
 
+// createConfigFile creates the default config file. A file given with
+// --config or $PZ_CONFIG has to exist, so that a typo in the path is not
+// taken for an empty config.
 func createConfigFile() error {
+	if cfgFile != "" || os.Getenv(envConfig) != "" {
+		p, err := getConfigPath()
+		if err != nil {
+			return err
+		}
+		if !Exists(p) {
+			return fmt.Errorf("  [ERR] Config file is not found: %v", p)
+		}
+		return nil
+	}
+
 	configDir, err := getConfigDir()
 	if err != nil {
 		return err
@@ -135,7 +183,19 @@ func createConfigFile() error {
 	return nil
 }
 
+// getConfigPath returns the config file. It is chosen in the order of the
+// --config flag, $PZ_CONFIG, $XDG_CONFIG_HOME/pz/config.toml and
+// ~/.config/pz/config.toml.
 func getConfigPath() (string, error) {
+	if cfgFile != "" {
+		return filepath.Abs(cfgFile)
+	}
+	if p := os.Getenv(envConfig); p != "" {
+		return filepath.Abs(p)
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "pz", "config.toml"), nil
+	}
 	u, err := user.Current()
 	if err != nil {
 		return "", err
@@ -144,22 +204,49 @@ func getConfigPath() (string, error) {
 	return p, nil
 }
 
+// getConfigDir returns the directory of the config file, which also holds
+// the templates and the saved session.
 func getConfigDir() (string, error) {
-	u, err := user.Current()
+	p, err := getConfigPath()
 	if err != nil {
 		return "", err
 	}
-	p := filepath.Join(u.HomeDir, ".config", "pz")
-	return p, nil
+	return filepath.Dir(p), nil
 }
 
+// getConfig returns the config with the environment variables applied.
+// Use readConfigFile to change and write the config back.
 func getConfig() (Config, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return Config{}, err
+	}
+	if v := os.Getenv(envEmail); v != "" {
+		config.User.Email = v
+	}
+	if v := os.Getenv(envDefaultTemplate); v != "" {
+		config.Setting.DefalutTemp = v
+	}
+	if v := os.Getenv(envWorkspace); v != "" {
+		config.Setting.Workspace = v
+	}
+	return config, nil
+}
+
+func readConfigFile() (Config, error) {
 	p, err := getConfigPath()
 	if err != nil {
 		return Config{}, err
 	}
 	var config Config
-	_, err = toml.DecodeFile(p, &config)
+	if !Exists(p) {
+		return config, nil
+	}
+	unknown, err := decodeFile(p, &config)
+	if err != nil {
+		return Config{}, err
+	}
+	warnUnknownKeys(p, unknown)
 	return config, nil
 }
 
@@ -181,4 +268,6 @@ func Exists(filename string) bool {
 
 func init() {
 	rootCmd.AddCommand(configCmd)
+
+	configCmd.Flags().SetInterspersed(false)
 }
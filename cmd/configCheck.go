@@ -0,0 +1,174 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkOK   = "OK"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// checkItem is a line of a check list.
+type checkItem struct {
+	status  string
+	name    string
+	message string
+	// hint tells how to fix a warning or a failure.
+	hint string
+}
+
+// configCheckCmd represents the config check command
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate config.toml and every template.toml",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		items, err := checkConfigFiles()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !printCheckItems(items) {
+			os.Exit(1)
+		}
+	},
+}
+
+// printCheckItems prints the check list, and reports whether nothing failed.
+func printCheckItems(items []checkItem) bool {
+	colors := map[string]string{checkOK: stdoutColor, checkWarn: tleColor, checkFail: stderrColor}
+	passed := true
+	for _, item := range items {
+		status := ansi.Color(fmt.Sprintf("[%v]", item.status), colors[item.status])
+		if item.message == "" {
+			fmt.Printf("  %v %v\n", status, item.name)
+		} else {
+			fmt.Printf("  %v %v: %v\n", status, item.name, item.message)
+		}
+		if item.hint != "" {
+			fmt.Printf("      -> %v\n", item.hint)
+		}
+		if item.status == checkFail {
+			passed = false
+		}
+	}
+	return passed
+}
+
+// errorMessage returns the message of err without the "[ERR]" prefix.
+func errorMessage(err error) string {
+	return strings.TrimPrefix(strings.TrimSpace(err.Error()), "[ERR] ")
+}
+
+// checkConfigFiles validates config.toml and the templates in the config
+// directory.
+func checkConfigFiles() ([]checkItem, error) {
+	p, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	items := []checkItem{}
+	name := "config.toml"
+	var config Config
+	if !Exists(p) {
+		items = append(items, checkItem{checkFail, name, "not found: " + p, "Run any pz command to create it"})
+	} else if unknown, err := decodeFile(p, &config); err != nil {
+		items = append(items, checkItem{checkFail, name, errorMessage(err), "Fix the line of " + p})
+	} else {
+		failed := false
+		for _, k := range unknown {
+			items = append(items, checkItem{checkWarn, name, "unknown key: " + k, "Check the spelling (see pz config list)"})
+		}
+		for _, k := range configKeys {
+			field := configField(&config, k.name)
+			if k.validate == nil || field.IsZero() {
+				continue
+			}
+			if err := k.validate(formatConfigValue(field)); err != nil {
+				failed = true
+				items = append(items, checkItem{checkFail, name, k.name + ": " + errorMessage(err), "Run pz config set " + k.name + " (or unset)"})
+			}
+		}
+		if !failed {
+			items = append(items, checkItem{checkOK, name + " (" + p + ")", "", ""})
+		}
+	}
+
+	configDir := filepath.Dir(p)
+	files, err := ioutil.ReadDir(configDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() && Exists(filepath.Join(configDir, f.Name(), "template.toml")) {
+			items = append(items, checkTemplate(configDir, f.Name())...)
+		}
+	}
+	return items, nil
+}
+
+// checkTemplate validates a template.toml and the commands in it.
+func checkTemplate(configDir, temp string) []checkItem {
+	name := "template " + temp
+	p := filepath.Join(configDir, temp, "template.toml")
+	var tempConfig templateConfig
+	unknown, err := decodeFile(p, &tempConfig)
+	if err != nil {
+		return []checkItem{{checkFail, name, errorMessage(err), "Fix the line of " + p}}
+	}
+	items := []checkItem{}
+	for _, k := range unknown {
+		items = append(items, checkItem{checkWarn, name, "unknown key: " + k, "Check the spelling in " + p})
+	}
+	fail := func(message, hint string) []checkItem {
+		return append(items, checkItem{checkFail, name, message, hint})
+	}
+	if tempConfig.File == "" {
+		return fail("file is not set", "Set the source file name to file in "+p)
+	}
+	if !Exists(filepath.Join(configDir, temp, tempConfig.File)) {
+		return fail("source file is not found: "+tempConfig.File, "Put "+tempConfig.File+" in "+filepath.Join(configDir, temp))
+	}
+	if tempConfig.Run == "" {
+		return fail("run is not set", "Set the execution command to run in "+p)
+	}
+	if !tempConfig.Shell {
+		for _, c := range []string{tempConfig.Run, tempConfig.Build, tempConfig.Debugger, tempConfig.Judge} {
+			if _, err := splitWords(c); err != nil {
+				return fail(errorMessage(err), "Quote the characters or set shell = true")
+			}
+		}
+	}
+	if _, err := newChecker(tempConfig.checkerConfig, commandContext{}); err != nil {
+		return fail(errorMessage(err), "Use exact, token, float or custom with judge")
+	}
+	return append(items, checkItem{checkOK, name, "", ""})
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+}
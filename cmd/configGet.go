@@ -16,28 +16,26 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
 )
 
-// emailCmd represents the email command
-var emailCmd = &cobra.Command{
-	Use:   "email",
-	Short: "Set your e-mail address for paiza.jp",
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get key",
+	Short: "Print the effective value of a setting",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		err := updateConfig("email", args[0])
-		if err != nil {
-			log.Fatalln(err)
-		}
-		err = printConfig()
+		value, _, err := getConfigValue(args[0])
 		if err != nil {
 			log.Fatalln(err)
 		}
+		fmt.Println(value)
 	},
 }
 
 func init() {
-	configCmd.AddCommand(emailCmd)
+	configCmd.AddCommand(configGetCmd)
 }
@@ -0,0 +1,60 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the effective settings and where they come from",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listConfig(); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+// listConfig prints every key with its value, source and description.
+func listConfig() error {
+	p, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Config: %v\n", p)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  KEY\tVALUE\tSOURCE\tDESCRIPTION")
+	for _, k := range configKeys {
+		value, source, err := getConfigValue(k.name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", k.name, value, source, k.description)
+	}
+	return w.Flush()
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// configKey describes a key of config.toml. The key is the toml name of a
+// field of UserConfig or SettingConfig.
+type configKey struct {
+	name        string
+	def         string
+	env         string
+	description string
+	// validate checks a value given to config set.
+	validate func(value string) error
+}
+
+// configKeys is the schema of config.toml in the order of config list.
+var configKeys = []configKey{
+	{name: "email", env: envEmail, description: "e-mail address for paiza.jp"},
+	{name: "password", env: envPassword, description: "password for paiza.jp (saved in the keyring or an encrypted file)"},
+	{name: "passwordStore", description: "where the password is saved", validate: oneOf(passwordStoreKeyring, passwordStoreFile)},
+	{name: "passwordEnv", description: "environment variable holding the password"},
+	{name: "passwordCommand", description: "command printing the password, e.g. \"pass show paiza\""},
+	{name: "defaultTemplate", env: envDefaultTemplate, description: "template used when none is given", validate: templateExists},
+	{name: "workspace", def: ".", env: envWorkspace, description: "directory where get creates the question directories", validate: dirExists},
+	{name: "timeout", def: strconv.FormatFloat(defaultTimeout, 'f', -1, 64), description: "time limit of each test case in seconds (negative: no limit)"},
+	{name: "memoryLimit", def: "0", description: "memory limit of each test case in megabytes (0: no limit)", validate: nonNegative},
+	{name: "jobs", def: "1", description: "number of test cases run in parallel", validate: nonNegative},
+	{name: "diffStyle", def: "unified", description: "diff format of wrong answers", validate: oneOf("unified", "side-by-side")},
+	{name: "diffLines", def: strconv.Itoa(defaultDiffLines), description: "maximum number of printed diff lines", validate: nonNegative},
+	{name: "browser", def: "chrome", description: "chrome, chromium, firefox or a WebDriver URL", validate: validBrowser},
+	{name: "headless", def: "false", description: "run the browser without a window"},
+	{name: "chromeArgs", description: "arguments passed to Chrome"},
+	{name: "chromeBinary", description: "path of the Chrome executable"},
+	{name: "debuggerAddress", description: "address of a running Chrome to attach to (host:port)", validate: hostPort},
+}
+
+func findConfigKey(name string) (configKey, error) {
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return configKey{}, fmt.Errorf("  [ERR] Unknown key: %v (see pz config list)", name)
+}
+
+// configField returns the field of config whose toml name is name.
+func configField(config *Config, name string) reflect.Value {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		section := v.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			tag := strings.Split(section.Type().Field(j).Tag.Get("toml"), ",")[0]
+			if tag == name {
+				return section.Field(j)
+			}
+		}
+	}
+	panic("config key without a field: " + name)
+}
+
+// formatConfigValue returns the value of the field as written to config set.
+func formatConfigValue(field reflect.Value) string {
+	if field.Kind() == reflect.Slice {
+		words := []string{}
+		for i := 0; i < field.Len(); i++ {
+			words = append(words, shellQuote(field.Index(i).String()))
+		}
+		return strings.Join(words, " ")
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+// setConfigField parses value by the type of the field. A list is given
+// as words like a command line.
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("  [ERR] Not a boolean: %v", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("  [ERR] Not an integer: %v", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("  [ERR] Not a number: %v", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		words, err := splitWords(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(words))
+	}
+	return nil
+}
+
+// getConfigValue returns the effective value of the key and where it comes
+// from: "env", "file", "default", the password store or "config (plaintext)".
+func getConfigValue(name string) (string, string, error) {
+	key, err := findConfigKey(name)
+	if err != nil {
+		return "", "", err
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return "", "", err
+	}
+	if key.name == "password" {
+		switch {
+		case os.Getenv(envPassword) != "":
+			return passwordMask, "env", nil
+		case config.User.PasswordCommand != "":
+			return passwordMask, "passwordCommand", nil
+		case config.User.PasswordEnv != "":
+			return passwordMask, "passwordEnv", nil
+		case config.User.PasswordStore != "":
+			return passwordMask, config.User.PasswordStore, nil
+		case config.User.Pass != "":
+			return passwordMask, "config (plaintext)", nil
+		}
+		return "", "default", nil
+	}
+	if key.env != "" && os.Getenv(key.env) != "" {
+		return os.Getenv(key.env), "env", nil
+	}
+	field := configField(&config, key.name)
+	if !field.IsZero() {
+		return formatConfigValue(field), "file", nil
+	}
+	return key.def, "default", nil
+}
+
+// setConfigValue validates the value and writes it to the config file.
+func setConfigValue(name, value string) error {
+	key, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if key.name == "password" {
+		if err := setPassword(&config, value); err != nil {
+			return err
+		}
+		return writeConfig(config)
+	}
+	if key.validate != nil {
+		if err := key.validate(value); err != nil {
+			return err
+		}
+	}
+	if key.name == "workspace" {
+		if value, err = filepath.Abs(value); err != nil {
+			return err
+		}
+	}
+	if err := setConfigField(configField(&config, key.name), value); err != nil {
+		return err
+	}
+	return writeConfig(config)
+}
+
+// unsetConfigValue removes the key from the config file, so that the
+// default value is used.
+func unsetConfigValue(name string) error {
+	key, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if key.name == "password" {
+		// The saved password is left in the keyring or the file.
+		config.User.Pass = ""
+		config.User.PasswordStore = ""
+		return writeConfig(config)
+	}
+	field := configField(&config, key.name)
+	field.Set(reflect.Zero(field.Type()))
+	return writeConfig(config)
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("  [ERR] Invalid value: %v (%v)", value, strings.Join(values, ", "))
+	}
+}
+
+func nonNegative(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err == nil && f < 0 {
+		return fmt.Errorf("  [ERR] Invalid value: %v (must not be negative)", value)
+	}
+	return nil
+}
+
+func templateExists(value string) error {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	if !Exists(filepath.Join(configDir, value, "template.toml")) {
+		return fmt.Errorf("  [ERR] Template: %v is not found", value)
+	}
+	return nil
+}
+
+func dirExists(value string) error {
+	if f, err := os.Stat(value); err != nil || !f.IsDir() {
+		return fmt.Errorf("  [ERR] Not a directory: %v", value)
+	}
+	return nil
+}
+
+func validBrowser(value string) error {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return nil
+	}
+	return oneOf("chrome", "chromium", "firefox", "geckodriver")(strings.ToLower(value))
+}
+
+func hostPort(value string) error {
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return fmt.Errorf("  [ERR] Invalid address: %v (host:port)", value)
+	}
+	return nil
+}
@@ -21,23 +21,23 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// passwordCmd represents the password command
-var passwordCmd = &cobra.Command{
-	Use:   "password",
-	Short: "Set your password for paiza.jp",
-	Args:  cobra.ExactArgs(1),
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set key value",
+	Short: "Change a setting",
+	Long: `Change a setting in config.toml.
+A list such as chromeArgs is given as words, e.g. "--no-sandbox --lang=ja".`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		err := updateConfig("password", args[0])
-		if err != nil {
-			log.Println(err)
-		}
-		err = printConfig()
-		if err != nil {
-			log.Println(err)
+		if err := setConfigValue(args[0], args[1]); err != nil {
+			log.Fatalln(err)
 		}
 	},
 }
 
 func init() {
-	configCmd.AddCommand(passwordCmd)
+	configCmd.AddCommand(configSetCmd)
+
+	// Values such as "-1" or "--no-sandbox" are not flags.
+	configSetCmd.Flags().SetInterspersed(false)
 }
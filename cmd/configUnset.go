@@ -21,21 +21,18 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// autoSubmitCmd represents the autoSubmit command
-var autoSubmitCmd = &cobra.Command{
-	Use:   "autoSubmit",
-	Short: "Set a flag of automatic submit",
+// configUnsetCmd represents the config unset command
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset key",
+	Short: "Remove a setting to use its default value",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := updateConfig("autoSubmit", args[0]); err != nil {
-			log.Fatalln(err)
-		}
-		if err := printConfig(); err != nil {
+		if err := unsetConfigValue(args[0]); err != nil {
 			log.Fatalln(err)
 		}
 	},
 }
 
 func init() {
-	// configCmd.AddCommand(autoSubmitCmd)
+	configCmd.AddCommand(configUnsetCmd)
 }
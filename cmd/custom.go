@@ -0,0 +1,182 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// getCustomDir returns the directory of the user-added test cases. It is
+// separated from the samples so that `get` never overwrites them.
+func getCustomDir(quesDir string) string {
+	return filepath.Join(quesDir, "tests", "custom")
+}
+
+// countCustomCases returns the number of custom cases, which are numbered
+// from input_0.txt without gaps.
+func countCustomCases(quesDir string) (int, error) {
+	customDir := getCustomDir(quesDir)
+	n := 0
+	for {
+		_, err := os.Stat(filepath.Join(customDir, fmt.Sprintf("input_%v.txt", n)))
+		if os.IsNotExist(err) {
+			return n, nil
+		} else if err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// addCase creates a new custom case with the editor.
+func addCase(quesDir string) error {
+	customDir := getCustomDir(quesDir)
+	if err := os.MkdirAll(customDir, 0755); err != nil {
+		return err
+	}
+	n, err := countCustomCases(quesDir)
+	if err != nil {
+		return err
+	}
+	inputPath := filepath.Join(customDir, fmt.Sprintf("input_%v.txt", n))
+	if err = writeFile(inputPath, ""); err != nil {
+		return err
+	}
+	if err = editCaseFiles(inputPath, filepath.Join(customDir, fmt.Sprintf("output_%v.txt", n))); err != nil {
+		os.Remove(inputPath)
+		return err
+	}
+	fmt.Printf("  Add: custom %v\n", n+1)
+	return nil
+}
+
+// saveCustomCase stores the input and the expected output as a new custom
+// case and returns its number.
+func saveCustomCase(quesDir, input, output string) (int, error) {
+	customDir := getCustomDir(quesDir)
+	if err := os.MkdirAll(customDir, 0755); err != nil {
+		return 0, err
+	}
+	n, err := countCustomCases(quesDir)
+	if err != nil {
+		return 0, err
+	}
+	if err = writeFile(filepath.Join(customDir, fmt.Sprintf("output_%v.txt", n)), output); err != nil {
+		return 0, err
+	}
+	if err = writeFile(filepath.Join(customDir, fmt.Sprintf("input_%v.txt", n)), input); err != nil {
+		return 0, err
+	}
+	return n + 1, nil
+}
+
+// editCase opens the files of the N-th custom case with the editor.
+func editCase(quesDir string, caseID int) error {
+	n, err := countCustomCases(quesDir)
+	if err != nil {
+		return err
+	}
+	if caseID < 1 || n < caseID {
+		return fmt.Errorf("  [ERR] Could not find a custom case: %v", caseID)
+	}
+	customDir := getCustomDir(quesDir)
+	return editCaseFiles(
+		filepath.Join(customDir, fmt.Sprintf("input_%v.txt", caseID-1)),
+		filepath.Join(customDir, fmt.Sprintf("output_%v.txt", caseID-1)))
+}
+
+// removeCase deletes the N-th custom case and renumbers the following ones.
+func removeCase(quesDir string, caseID int) error {
+	n, err := countCustomCases(quesDir)
+	if err != nil {
+		return err
+	}
+	if caseID < 1 || n < caseID {
+		return fmt.Errorf("  [ERR] Could not find a custom case: %v", caseID)
+	}
+	customDir := getCustomDir(quesDir)
+	for _, name := range []string{"input_%v.txt", "output_%v.txt"} {
+		p := filepath.Join(customDir, fmt.Sprintf(name, caseID-1))
+		if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := caseID; i < n; i++ {
+			src := filepath.Join(customDir, fmt.Sprintf(name, i))
+			dst := filepath.Join(customDir, fmt.Sprintf(name, i-1))
+			if err = os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	fmt.Printf("  Remove: custom %v\n", caseID)
+	return nil
+}
+
+// editCaseFiles edits the input and then the expected output. The expected
+// output is optional and removed when it is left empty.
+func editCaseFiles(inputPath, outputPath string) error {
+	fmt.Println("  Edit the input.")
+	if err := openEditor(inputPath); err != nil {
+		return err
+	}
+	fmt.Println("  Edit the expected output. (leave it empty to skip)")
+	if err := openEditor(outputPath); err != nil {
+		return err
+	}
+	output, err := ioutil.ReadFile(outputPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		if err = os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// openEditor opens the file with $VISUAL or $EDITOR and waits until it is closed.
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+	words, err := splitWords(editor)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("  [ERR] Set an editor to $EDITOR")
+	}
+	cmd := exec.Command(words[0], append(words[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
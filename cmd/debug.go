@@ -0,0 +1,277 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+const (
+	inputColor = "cyan"
+
+	// debugInputLines caps the number of input lines shown by debug.
+	debugInputLines = 50
+)
+
+var debugTemp string
+var debugEditor bool
+
+// debugCmd represents the debug command
+var debugCmd = &cobra.Command{
+	Use:   "debug [dir|ID] (N | - | -e)",
+	Short: "Run a test case showing its output without a browser",
+	Long: `Run a test case showing its input and output without a browser.
+Give "-" instead of N to type an input, or -e to write it with $EDITOR.`,
+	Args: cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		temp, err := resolveTemplate(debugTemp)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !debugEditor && len(args) == 0 {
+			log.Fatalln("  [ERR] Select a number that you want to debug.")
+		}
+		adHoc := debugEditor || args[len(args)-1] == "-"
+		var programID int
+		if !adHoc {
+			// e.g. "pz debug D/D170" without N
+			if programID, err = strconv.Atoi(args[len(args)-1]); err != nil {
+				log.Fatalln("  [ERR] Select a number that you want to debug.")
+			}
+		}
+		var arg string
+		if len(args) == 2 || (debugEditor && len(args) == 1) {
+			arg = args[0]
+		}
+		quesDir, err := resolveQuesDir(arg)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		var finished bool
+		if adHoc {
+			finished, err = debugAdHoc(temp, quesDir, bufio.NewScanner(os.Stdin), debugEditor)
+		} else {
+			finished, err = debug(temp, quesDir, programID)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !finished {
+			os.Exit(1)
+		}
+	},
+}
+
+// debug runs a sample case showing its output, and reports whether the
+// program finished normally.
+func debug(temp, quesDir string, programID int) (bool, error) {
+	inputPath, err := getSampleInput(quesDir, programID)
+	if err != nil {
+		return false, err
+	}
+	input, err := ioutil.ReadFile(inputPath)
+	if err != nil {
+		return false, err
+	}
+	return debugInput(temp, quesDir, fmt.Sprintf("case %v", programID), input)
+}
+
+func getSampleInput(quesDir string, programID int) (string, error) {
+	inputPath := filepath.Join(quesDir, "tests", fmt.Sprintf("input_%v.txt", programID-1))
+	if programID < 1 || !Exists(inputPath) {
+		return "", fmt.Errorf("  [ERR] Could not find a sample: %v", programID)
+	}
+	return inputPath, nil
+}
+
+// debugAdHoc runs the program with an input typed in the terminal, or
+// written with the editor when useEditor is set.
+func debugAdHoc(temp, quesDir string, scanner *bufio.Scanner, useEditor bool) (bool, error) {
+	var input []byte
+	if useEditor {
+		f, err := ioutil.TempFile("", "pz-input-*.txt")
+		if err != nil {
+			return false, err
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+		if err = openEditor(f.Name()); err != nil {
+			return false, err
+		}
+		if input, err = ioutil.ReadFile(f.Name()); err != nil {
+			return false, err
+		}
+	} else {
+		fmt.Println("  Enter the input. (finish with a line of '.' or Ctrl-D)")
+		var buf bytes.Buffer
+		for scanner.Scan() {
+			if scanner.Text() == "." {
+				break
+			}
+			buf.WriteString(scanner.Text() + "\n")
+		}
+		input = buf.Bytes()
+	}
+	return debugInput(temp, quesDir, "ad-hoc input", input)
+}
+
+// debugInput shows the input, then the lines of stdout (>>) and stderr
+// (!!) with the time they arrived. Note that most programs buffer stdout
+// when it is not a terminal, which delays its lines.
+func debugInput(temp, quesDir, label string, input []byte) (bool, error) {
+	if temp == "" {
+		return false, fmt.Errorf("  [ERR] Select a template.")
+	}
+	tempConfig, _, err := getTemplateConfig(temp)
+	if err != nil {
+		return false, err
+	}
+	cmdCtx := newCommandContext(tempConfig, quesDir)
+	timeout, err := getTimeout(tempConfig)
+	if err != nil {
+		return false, err
+	}
+	built, err := buildProgram(tempConfig, cmdCtx, os.Stdout)
+	if err != nil {
+		return false, err
+	}
+	if !built {
+		return false, nil
+	}
+	fmt.Printf("  Debug: %v\n", label)
+	fmt.Println("  [Input]")
+	lines := strings.Split(strings.TrimRight(string(input), "\n"), "\n")
+	for i, l := range lines {
+		if i == debugInputLines {
+			fmt.Printf("    ... (%v more lines)\n", len(lines)-i)
+			break
+		}
+		fmt.Printf("    << %v\n", ansi.Color(l, inputColor))
+	}
+	fmt.Println("  [Output]")
+	cmd, err := cmdCtx.command(tempConfig.Run)
+	if err != nil {
+		return false, err
+	}
+	cmd.Stdin = bytes.NewBuffer(input)
+	result, err := runCommand(cmd, true, timeout)
+	if err != nil {
+		return false, err
+	}
+	if result.timedOut {
+		outText := fmt.Sprintf("  [TLE] Time limit exceeded. (time:%vs, limit:%vs)", result.elapsed.Seconds(), timeout.Seconds())
+		fmt.Println(ansi.Color(outText, tleColor))
+	} else if result.signal != "" {
+		outText := fmt.Sprintf("  [RE] Killed by signal: %v (time:%vs)", result.signal, result.elapsed.Seconds())
+		fmt.Println(ansi.Color(outText, reColor))
+	} else if result.exitCode != 0 {
+		outText := fmt.Sprintf("  [RE] Exited with code %v (time:%vs)", result.exitCode, result.elapsed.Seconds())
+		fmt.Println(ansi.Color(outText, reColor))
+	} else {
+		fmt.Printf("  [Finish] %v\n", formatUsage(result))
+		return true, nil
+	}
+	return false, nil
+}
+
+// runDebugger hands the terminal over to the debugger command of the
+// template with the input of the case redirected to the program. A command
+// using {input} is run as written, and the terminal stays its stdin.
+func runDebugger(temp, quesDir string, programID int) error {
+	if temp == "" {
+		return fmt.Errorf("  [ERR] Select a template.")
+	}
+	tempConfig, _, err := getTemplateConfig(temp)
+	if err != nil {
+		return err
+	}
+	if tempConfig.Debugger == "" {
+		return fmt.Errorf("  [ERR] Set a debugger command to template.toml")
+	}
+	inputPath, err := getSampleInput(quesDir, programID)
+	if err != nil {
+		return err
+	}
+	cmdCtx := newCommandContext(tempConfig, quesDir)
+	built, err := buildProgram(tempConfig, cmdCtx, os.Stdout)
+	if err != nil || !built {
+		return err
+	}
+	cmdCtx.vars["input"] = inputPath
+	cmd, err := cmdCtx.command(tempConfig.Debugger)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = os.Stdin
+	if !strings.Contains(tempConfig.Debugger, "{input}") {
+		redirected := false
+		if !tempConfig.Shell {
+			args, ok, cleanup, err := redirectInput(cmd.Args, inputPath)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			cmd.Args, redirected = args, ok
+		}
+		if !redirected {
+			// Tools like valgrind do not read the terminal themselves.
+			f, err := os.Open(inputPath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			cmd.Stdin = f
+		}
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Ctrl-C is meant for the debugger, which shares the terminal with pz.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	fmt.Printf("  Debugger: case %v\n", programID)
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		fmt.Printf("  [Debugger] Exited with code %v\n", exitErr.ExitCode())
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Println("  [Debugger] Finished")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+
+	debugCmd.Flags().StringVarP(&debugTemp, "template", "t", "", "template name (default: defaultTemplate)")
+	debugCmd.Flags().BoolVarP(&debugEditor, "editor", "e", false, "write an input with $EDITOR")
+}
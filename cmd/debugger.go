@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pdbWrapper runs a script under pdb like "python -m pdb", except that the
+// script reads sys.stdin from the input file while pdb keeps the terminal.
+const pdbWrapper = `import os, pdb, sys, traceback
+input_path, sys.argv = sys.argv[1], sys.argv[2:]
+sys.path[0] = os.path.dirname(os.path.abspath(sys.argv[0]))
+with open(sys.argv[0]) as f:
+    code = compile(f.read(), sys.argv[0], "exec")
+debugger = pdb.Pdb(stdin=sys.stdin, stdout=sys.stdout)
+sys.stdin = open(input_path)
+try:
+    debugger.run(code, {"__name__": "__main__", "__file__": sys.argv[0], "__builtins__": __builtins__})
+except SystemExit:
+    pass
+except Exception:
+    traceback.print_exc()
+    debugger.reset()
+    debugger.interaction(None, sys.exc_info()[2])
+`
+
+// redirectInput rewrites the arguments of a debugger which reads its own
+// commands from stdin, so that the program it starts reads inputPath
+// instead. gdb, lldb, dlv and "python -m pdb" are supported. It reports
+// false for other commands, whose stdin can simply be the input file.
+// The returned function removes the files created for the rewrite.
+func redirectInput(args []string, inputPath string) ([]string, bool, func(), error) {
+	noop := func() {}
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(args[0]), ".exe"))
+	switch {
+	case name == "gdb":
+		// "set args" replaces the arguments given with --args, so they are
+		// repeated in front of the redirection.
+		setArgs := "< " + shellQuote(inputPath)
+		for i, a := range args {
+			if a == "--args" && i+2 <= len(args) {
+				var quoted []string
+				for _, b := range args[i+2:] {
+					quoted = append(quoted, shellQuote(b))
+				}
+				setArgs = strings.Join(append(quoted, setArgs), " ")
+				break
+			}
+		}
+		return insertArgs(args, 1, "-ex", "set args "+setArgs), true, noop, nil
+	case name == "lldb":
+		return insertArgs(args, 1, "-o", `settings set target.input-path "`+inputPath+`"`), true, noop, nil
+	case name == "dlv":
+		return insertArgs(args, 1, "--redirect=stdin:"+inputPath), true, noop, nil
+	case strings.HasPrefix(name, "python") || name == "py":
+		for i := 1; i+1 < len(args); i++ {
+			if args[i] == "-m" && args[i+1] == "pdb" {
+				dir, err := ioutil.TempDir("", "pz-pdb-")
+				if err != nil {
+					return nil, false, nil, err
+				}
+				wrapperPath := filepath.Join(dir, "pdb_wrapper.py")
+				if err := ioutil.WriteFile(wrapperPath, []byte(pdbWrapper), 0644); err != nil {
+					os.RemoveAll(dir)
+					return nil, false, nil, err
+				}
+				rewritten := append(append(append([]string{}, args[:i]...), wrapperPath, inputPath), args[i+2:]...)
+				return rewritten, true, func() { os.RemoveAll(dir) }, nil
+			}
+			if !strings.HasPrefix(args[i], "-") {
+				break
+			}
+		}
+	}
+	return args, false, noop, nil
+}
+
+// insertArgs returns a copy of args with extra inserted at i.
+func insertArgs(args []string, i int, extra ...string) []string {
+	return append(append(append([]string{}, args[:i]...), extra...), args[i:]...)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// decodeFile decodes a TOML file into v. Unlike toml.DecodeFile, the error
+// tells the file, the line and the key, and the keys which v does not have
+// are returned so that a typo can be warned about.
+func decodeFile(path string, v interface{}) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data := string(b)
+	md, err := toml.Decode(data, v)
+	if pe, ok := err.(toml.ParseError); ok {
+		return nil, fmt.Errorf("  [ERR] %v:%v: %v (last key: %v)", path, pe.Line, pe.Message, pe.LastKey)
+	} else if err != nil {
+		if key := findBadKey(data, reflect.TypeOf(v).Elem()); key != nil {
+			return nil, fmt.Errorf("  [ERR] %v:%v: %v: %v", path, keyLine(data, key), key, err)
+		}
+		return nil, fmt.Errorf("  [ERR] %v: %v", path, err)
+	}
+	unknown := []string{}
+	for _, k := range md.Undecoded() {
+		unknown = append(unknown, k.String())
+	}
+	return unknown, nil
+}
+
+// warnedFiles keeps the files already warned about, since the config is
+// read many times in a run.
+var warnedFiles = map[string]bool{}
+
+// warnUnknownKeys prints the unknown keys of a file once.
+func warnUnknownKeys(path string, keys []string) {
+	if len(keys) == 0 || warnedFiles[path] {
+		return
+	}
+	warnedFiles[path] = true
+	for _, k := range keys {
+		fmt.Fprintf(os.Stderr, "  [WARN] %v: Unknown key: %v\n", path, k)
+	}
+}
+
+// findBadKey decodes the values one by one into a new value of type t,
+// and returns the key of the first value which fails.
+func findBadKey(data string, t reflect.Type) toml.Key {
+	var raw map[string]interface{}
+	md, err := toml.Decode(data, &raw)
+	if err != nil {
+		return nil
+	}
+	for _, key := range md.Keys() {
+		if md.Type(key...) == "Hash" {
+			continue
+		}
+		// Rebuild a document holding only this key.
+		single := map[string]interface{}{}
+		m, src := single, raw
+		for _, k := range key[:len(key)-1] {
+			next, ok := src[k].(map[string]interface{})
+			if !ok {
+				break
+			}
+			m[k] = map[string]interface{}{}
+			m, src = m[k].(map[string]interface{}), next
+		}
+		m[key[len(key)-1]] = src[key[len(key)-1]]
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(single); err != nil {
+			continue
+		}
+		if _, err := toml.Decode(buf.String(), reflect.New(t).Interface()); err != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+// keyLine returns the line number where key is defined, or 0.
+func keyLine(data string, key toml.Key) int {
+	table := strings.Join(key[:len(key)-1], ".")
+	name := key[len(key)-1]
+	current := ""
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			current = strings.Trim(line, "[] \t")
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 || current != table {
+			continue
+		}
+		if strings.Trim(strings.TrimSpace(line[:eq]), `"'`) == name {
+			return i + 1
+		}
+	}
+	return 0
+}
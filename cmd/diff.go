@@ -0,0 +1,279 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mgutz/ansi"
+)
+
+const (
+	expectedColor = "red"
+	actualColor   = "green"
+	contextColor  = ""
+
+	// diffContext is the number of unchanged lines shown around a change.
+	diffContext = 2
+	// defaultDiffLines caps the number of printed diff lines.
+	defaultDiffLines = 50
+	// maxDiffCells bounds the LCS table of the lines between the common
+	// prefix and suffix (4MB). Larger outputs are compared line by line
+	// instead.
+	maxDiffCells = 1 << 20
+
+	diffStyleUnified    = "unified"
+	diffStyleSideBySide = "side-by-side"
+	sideBySideWidth     = 36
+)
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine is a line of the edit script. Delete lines come from the
+// expected output and insert lines from the actual one.
+type diffLine struct {
+	kind     diffKind
+	text     string
+	expected int
+	actual   int
+}
+
+// diffOutput computes a line-based edit script turning expected into actual.
+func diffOutput(expected, actual []string) []diffLine {
+	// Only the lines between the common prefix and suffix need the table.
+	prefix := 0
+	for prefix < len(expected) && prefix < len(actual) && expected[prefix] == actual[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(expected)-prefix && suffix < len(actual)-prefix &&
+		expected[len(expected)-1-suffix] == actual[len(actual)-1-suffix] {
+		suffix++
+	}
+	lines := []diffLine{}
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, diffLine{diffEqual, expected[i], i, i})
+	}
+	for _, l := range diffMiddle(expected[prefix:len(expected)-suffix], actual[prefix:len(actual)-suffix]) {
+		l.expected += prefix
+		l.actual += prefix
+		lines = append(lines, l)
+	}
+	for k := suffix; k > 0; k-- {
+		i, j := len(expected)-k, len(actual)-k
+		lines = append(lines, diffLine{diffEqual, expected[i], i, j})
+	}
+	return lines
+}
+
+func diffMiddle(expected, actual []string) []diffLine {
+	n, m := len(expected), len(actual)
+	if n*m > maxDiffCells {
+		return diffByPosition(expected, actual)
+	}
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && expected[i] == actual[j] {
+			lines = append(lines, diffLine{diffEqual, expected[i], i, j})
+			i++
+			j++
+		} else if j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]) {
+			lines = append(lines, diffLine{diffDelete, expected[i], i, j})
+			i++
+		} else {
+			lines = append(lines, diffLine{diffInsert, actual[j], i, j})
+			j++
+		}
+	}
+	return lines
+}
+
+func diffByPosition(expected, actual []string) []diffLine {
+	lines := []diffLine{}
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i < len(expected) && i < len(actual) && expected[i] == actual[i] {
+			lines = append(lines, diffLine{diffEqual, expected[i], i, i})
+			continue
+		}
+		if i < len(expected) {
+			lines = append(lines, diffLine{diffDelete, expected[i], i, i})
+		}
+		if i < len(actual) {
+			lines = append(lines, diffLine{diffInsert, actual[i], i, i})
+		}
+	}
+	return lines
+}
+
+// printDiff prints the difference between the expected and actual output
+// under a failing test case.
+func printDiff(expected, actual []string, style string, maxLines int, w io.Writer) {
+	if maxLines <= 0 {
+		maxLines = defaultDiffLines
+	}
+	lines := diffOutput(expected, actual)
+	first := -1
+	for i, l := range lines {
+		if l.kind != diffEqual {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return
+	}
+	fmt.Fprintf(w, "      First mismatch: line %v\n", lines[first].expected+1)
+	if style == diffStyleSideBySide {
+		printSideBySide(lines, first, maxLines, w)
+	} else {
+		printUnified(lines, first, maxLines, w)
+	}
+}
+
+func printUnified(lines []diffLine, first, maxLines int, w io.Writer) {
+	fmt.Fprintln(w, "      "+ansi.Color("--- expected", expectedColor))
+	fmt.Fprintln(w, "      "+ansi.Color("+++ actual", actualColor))
+	printed := 0
+	last := -1
+	for i, l := range lines {
+		if !nearChange(lines, i) {
+			continue
+		}
+		if printed >= maxLines {
+			fmt.Fprintf(w, "      ... (%v more changed lines)\n", countChanges(lines[i:]))
+			return
+		}
+		if last < 0 || i != last+1 {
+			fmt.Fprintln(w, "      "+ansi.Color(fmt.Sprintf("@@ expected:%v actual:%v @@", l.expected+1, l.actual+1), "cyan"))
+		}
+		var text string
+		switch l.kind {
+		case diffDelete:
+			text = ansi.Color("- "+l.text, highlight(expectedColor, i == first))
+		case diffInsert:
+			text = ansi.Color("+ "+l.text, highlight(actualColor, i == first))
+		default:
+			text = ansi.Color("  "+l.text, contextColor)
+		}
+		fmt.Fprintln(w, "      "+text)
+		printed++
+		last = i
+	}
+}
+
+func printSideBySide(lines []diffLine, first, maxLines int, w io.Writer) {
+	fmt.Fprintf(w, "      %-*s | %s\n", sideBySideWidth, "expected", "actual")
+	printed := 0
+	last := -1
+	for i := 0; i < len(lines); i++ {
+		if !nearChange(lines, i) {
+			continue
+		}
+		if printed >= maxLines {
+			fmt.Fprintf(w, "      ... (%v more changed lines)\n", countChanges(lines[i:]))
+			return
+		}
+		if last >= 0 && i != last+1 {
+			fmt.Fprintln(w, "      ...")
+		}
+		l := lines[i]
+		var left, right string
+		leftColor, rightColor := contextColor, contextColor
+		switch l.kind {
+		case diffEqual:
+			left, right = l.text, l.text
+		case diffDelete:
+			left, leftColor = l.text, highlight(expectedColor, i == first)
+			// pair a deleted line with the following inserted one
+			if i+1 < len(lines) && lines[i+1].kind == diffInsert {
+				i++
+				right, rightColor = lines[i].text, highlight(actualColor, i-1 == first)
+			}
+		case diffInsert:
+			right, rightColor = l.text, highlight(actualColor, i == first)
+		}
+		mark := " "
+		if l.kind != diffEqual {
+			mark = "|"
+		}
+		fmt.Fprintf(w, "      %s %s %s\n",
+			ansi.Color(fmt.Sprintf("%-*s", sideBySideWidth, truncate(left, sideBySideWidth)), leftColor),
+			mark,
+			ansi.Color(truncate(right, sideBySideWidth), rightColor))
+		printed++
+		last = i
+	}
+}
+
+// countChanges returns the number of deleted and inserted lines.
+func countChanges(lines []diffLine) int {
+	n := 0
+	for _, l := range lines {
+		if l.kind != diffEqual {
+			n++
+		}
+	}
+	return n
+}
+
+// nearChange reports whether the i-th line is a change or lies within
+// diffContext lines of one.
+func nearChange(lines []diffLine, i int) bool {
+	for j := i - diffContext; j <= i+diffContext; j++ {
+		if 0 <= j && j < len(lines) && lines[j].kind != diffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func highlight(color string, first bool) string {
+	if first {
+		return color + "+bu"
+	}
+	return color
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "~"
+}
@@ -0,0 +1,285 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment needed to use pz",
+	Long: `Check the WebDriver and the browser, the clipboard, config.toml and
+the templates, the commands used by the templates, and the workspace.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		items, err := doctor()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !printCheckItems(items) {
+			os.Exit(1)
+		}
+	},
+}
+
+func doctor() ([]checkItem, error) {
+	items := []checkItem{}
+	configItems, err := checkConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	config, err := getConfig()
+	if err != nil {
+		// The failure is already in the config items.
+		config = Config{}
+	}
+	items = append(items, checkDriver(config.Setting)...)
+	items = append(items, checkClipboard())
+	items = append(items, configItems...)
+	templateItems, err := checkTemplateCommands()
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, templateItems...)
+	items = append(items, checkWorkspace())
+	return items, nil
+}
+
+// versionPattern matches the version in the output of --version.
+var versionPattern = regexp.MustCompile(`(\d+)\.[\d.]+`)
+
+// commandVersion runs "name --version" and returns the version and its
+// major number.
+func commandVersion(name string) (string, string, error) {
+	out, err := exec.Command(name, "--version").Output()
+	if err != nil {
+		return "", "", err
+	}
+	m := versionPattern.FindStringSubmatch(string(out))
+	if m == nil {
+		return "", "", fmt.Errorf("unknown version: %v", strings.TrimSpace(string(out)))
+	}
+	return m[0], m[1], nil
+}
+
+// checkDriver checks the WebDriver for the browser setting, and for Chrome
+// that its major version matches the one of the browser.
+func checkDriver(setting SettingConfig) []checkItem {
+	browser := strings.ToLower(setting.Browser)
+	if strings.HasPrefix(browser, "http://") || strings.HasPrefix(browser, "https://") {
+		return []checkItem{{checkOK, "WebDriver", "remote " + setting.Browser, ""}}
+	}
+	if browser == "firefox" || browser == "geckodriver" {
+		item := checkItem{checkOK, "geckodriver", "", ""}
+		if _, err := exec.LookPath("geckodriver"); err != nil {
+			item = checkItem{checkFail, "geckodriver", "not found on PATH", "Download geckodriver from https://github.com/mozilla/geckodriver/releases and put it on PATH"}
+		} else if version, _, err := commandVersion("geckodriver"); err == nil {
+			item.message = version
+		}
+		return []checkItem{item}
+	}
+
+	if _, err := exec.LookPath("chromedriver"); err != nil {
+		return []checkItem{{checkFail, "chromedriver", "not found on PATH", "Download the chromedriver for your Chrome from https://chromedriver.chromium.org/downloads and put it on PATH"}}
+	}
+	driverVersion, driverMajor, err := commandVersion("chromedriver")
+	if err != nil {
+		return []checkItem{{checkFail, "chromedriver", err.Error(), "Reinstall chromedriver"}}
+	}
+	items := []checkItem{{checkOK, "chromedriver", driverVersion, ""}}
+	if setting.DebuggerAddress != "" {
+		// The version of an attached Chrome is not known until connected.
+		return items
+	}
+	chrome := findChrome(setting, browser)
+	if chrome == "" {
+		return append(items, checkItem{checkFail, "browser", "Chrome is not found", "Install Chrome, or set chromeBinary (pz config set chromeBinary PATH)"})
+	}
+	browserVersion, browserMajor, err := chromeVersion(chrome)
+	if err != nil {
+		return append(items, checkItem{checkWarn, "browser", "could not get the version of " + chrome, ""})
+	}
+	if browserMajor != driverMajor {
+		items[0] = checkItem{checkFail, "chromedriver", fmt.Sprintf("%v does not match the browser %v", driverVersion, browserVersion),
+			"Download chromedriver " + browserMajor + " from https://chromedriver.chromium.org/downloads"}
+	}
+	return append(items, checkItem{checkOK, "browser", browserVersion + " (" + chrome + ")", ""})
+}
+
+// findChrome returns the path of the browser run by chromedriver, or "".
+func findChrome(setting SettingConfig, browser string) string {
+	if setting.ChromeBinary != "" {
+		return setting.ChromeBinary
+	}
+	names := []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser"}
+	if browser == "chromium" {
+		names = []string{"chromium", "chromium-browser"}
+	}
+	if runtime.GOOS == "darwin" {
+		names = append(names, "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome")
+	}
+	if runtime.GOOS == "windows" {
+		// The installer does not put chrome.exe on PATH.
+		app := filepath.Join("Google", "Chrome", "Application", "chrome.exe")
+		if browser == "chromium" {
+			app = filepath.Join("Chromium", "Application", "chrome.exe")
+		}
+		names = nil
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LocalAppData"} {
+			if dir := os.Getenv(env); dir != "" {
+				names = append(names, filepath.Join(dir, app))
+			}
+		}
+	}
+	for _, name := range names {
+		if p, err := exec.LookPath(name); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// chromeVersion returns the version of Chrome and its major number.
+// chrome.exe does not print its version, but is installed next to a
+// directory named after it.
+func chromeVersion(chrome string) (string, string, error) {
+	if runtime.GOOS != "windows" {
+		return commandVersion(chrome)
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(chrome))
+	if err != nil {
+		return "", "", err
+	}
+	var version, major string
+	best := -1
+	for _, f := range files {
+		m := versionPattern.FindStringSubmatch(f.Name())
+		if !f.IsDir() || m == nil || m[0] != f.Name() {
+			continue
+		}
+		// A new version sits beside the old one until Chrome restarts.
+		if n, _ := strconv.Atoi(m[1]); n > best {
+			version, major, best = m[0], m[1], n
+		}
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("unknown version: %v", chrome)
+	}
+	return version, major, nil
+}
+
+func checkClipboard() checkItem {
+	if clipboard.Unsupported {
+		return checkItem{checkWarn, "clipboard", "not available (submit cannot copy the code)", "Install xclip, xsel or wl-clipboard"}
+	}
+	return checkItem{checkOK, "clipboard", "", ""}
+}
+
+// checkTemplateCommands checks that the program run by each command of
+// the templates is on PATH.
+func checkTemplateCommands() ([]checkItem, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(configDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	items := []checkItem{}
+	for _, f := range files {
+		if !f.IsDir() || !Exists(filepath.Join(configDir, f.Name(), "template.toml")) {
+			continue
+		}
+		tempConfig, _, err := getTemplateConfig(f.Name())
+		if err != nil {
+			// Reported by checkConfigFiles.
+			continue
+		}
+		name := "template " + f.Name() + " commands"
+		found := []string{}
+		var failed *checkItem
+		for _, c := range []string{tempConfig.Build, tempConfig.Run, tempConfig.Debugger, tempConfig.Judge} {
+			program := commandProgram(c, tempConfig.Shell)
+			if program == "" {
+				continue
+			}
+			if _, err := exec.LookPath(program); err != nil {
+				failed = &checkItem{checkFail, name, program + " is not found on PATH", "Install " + program + " or fix the command in template.toml"}
+				break
+			}
+			found = append(found, program)
+		}
+		if failed != nil {
+			items = append(items, *failed)
+		} else {
+			items = append(items, checkItem{checkOK, name, strings.Join(found, ", "), ""})
+		}
+	}
+	return items, nil
+}
+
+// commandProgram returns the program run by a command, or "" when it is
+// built in the problem directory, such as {binary} or ./main.
+func commandProgram(cmdText string, shell bool) string {
+	var words []string
+	if shell {
+		words = strings.Fields(cmdText)
+	} else {
+		var err error
+		if words, err = splitWords(cmdText); err != nil {
+			return ""
+		}
+	}
+	if len(words) == 0 || strings.Contains(words[0], "{") || strings.HasPrefix(words[0], ".") {
+		return ""
+	}
+	return words[0]
+}
+
+// checkWorkspace checks that get can create the question directories.
+func checkWorkspace() checkItem {
+	workspace, err := getWorkspace()
+	if err != nil {
+		return checkItem{checkFail, "workspace", errorMessage(err), ""}
+	}
+	hint := "Set a writable directory with pz config set workspace DIR or PZ_WORKSPACE"
+	f, err := ioutil.TempFile(workspace, ".pz-doctor-")
+	if err != nil {
+		return checkItem{checkFail, "workspace", workspace + " is not writable", hint}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return checkItem{checkOK, "workspace", workspace, ""}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// transcriptWriter records the exchange between the solution and the
+// interactor, prefixing each line with its direction.
+type transcriptWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// direction returns a writer for one side of the exchange.
+func (t *transcriptWriter) direction(prefix string) io.Writer {
+	lineStart := true
+	return writerFunc(func(p []byte) (int, error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for _, b := range p {
+			if lineStart {
+				t.buf.WriteString(prefix)
+			}
+			t.buf.WriteByte(b)
+			lineStart = b == '\n'
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// getTranscriptPath returns where the exchange of a case is saved.
+func getTranscriptPath(quesDir, label string) string {
+	return filepath.Join(quesDir, "transcripts", strings.Replace(label, " ", "_", -1)+".txt")
+}
+
+// runInteractive connects the solution and the interactor of the problem
+// with pipes. The interactor receives the paths of the input and the
+// expected output, and its exit code decides the verdict.
+func (r testRunner) runInteractive(tc testCase) caseResult {
+	solCmd, err := r.cmdCtx.command(r.tempConfig.Run)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	interCmd, err := r.cmdCtx.command(r.probConfig.Interactor, tc.inputPath, tc.outputPath)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	var solErr, interErr, solOutBuf bytes.Buffer
+	solCmd.Stderr = &solErr
+	interCmd.Stderr = &interErr
+
+	solIn, err := solCmd.StdinPipe()
+	if err != nil {
+		return caseResult{err: err}
+	}
+	solOut, err := solCmd.StdoutPipe()
+	if err != nil {
+		return caseResult{err: err}
+	}
+	interIn, err := interCmd.StdinPipe()
+	if err != nil {
+		return caseResult{err: err}
+	}
+	interOut, err := interCmd.StdoutPipe()
+	if err != nil {
+		return caseResult{err: err}
+	}
+
+	transcript := &transcriptWriter{}
+	interGroup, err := startProcessGroup(interCmd)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	defer interGroup.release()
+	start := time.Now()
+	solGroup, err := startProcessGroup(solCmd)
+	if err != nil {
+		interGroup.kill()
+		interCmd.Wait()
+		return caseResult{err: err}
+	}
+	defer solGroup.release()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		relay(io.MultiWriter(interIn, transcript.direction("< ")), solOut, &solOutBuf)
+		interIn.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		relay(io.MultiWriter(solIn, transcript.direction("> ")), interOut, nil)
+		solIn.Close()
+	}()
+
+	done := make(chan struct{})
+	var solWait, interWait error
+	go func() {
+		wg.Wait()
+		solWait = solCmd.Wait()
+		interWait = interCmd.Wait()
+		close(done)
+	}()
+	var result runResult
+	if r.timeout > 0 {
+		timer := time.NewTimer(r.timeout)
+		select {
+		case <-done:
+			timer.Stop()
+		case <-timer.C:
+			result.timedOut = true
+			solGroup.kill()
+			interGroup.kill()
+			<-done
+		}
+	} else {
+		<-done
+	}
+	result.elapsed = time.Since(start)
+	result.stdout = solOutBuf.String()
+	result.stderr = solErr.String()
+	if solCmd.ProcessState != nil {
+		result.cpuTime = solCmd.ProcessState.UserTime() + solCmd.ProcessState.SystemTime()
+		result.memory = peakMemory(solCmd.ProcessState)
+		if s, ok := solCmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+			result.exitCode = s.ExitStatus()
+			if s.Signaled() {
+				result.signal = s.Signal().String()
+			}
+		}
+	}
+	if _, ok := solWait.(*exec.ExitError); solWait != nil && !ok {
+		return caseResult{err: solWait}
+	}
+
+	transcriptPath := getTranscriptPath(r.cmdCtx.dir, tc.label)
+	if err = os.MkdirAll(filepath.Dir(transcriptPath), 0755); err != nil {
+		return caseResult{err: err}
+	}
+	if err = writeFile(transcriptPath, transcript.buf.String()); err != nil {
+		return caseResult{err: err}
+	}
+
+	if _, ok := interWait.(*exec.ExitError); interWait != nil && !ok {
+		return caseResult{err: interWait}
+	}
+
+	// The interactor is trusted over the solution, which usually crashes
+	// on EOF once the interactor has rejected it.
+	cr := caseResult{result: result, interactive: true}
+	if result.timedOut {
+		cr.verdict = verdictTLE
+	} else if interWait != nil {
+		cr.verdict = verdictWA
+		cr.message = strings.TrimSpace(interErr.String())
+	} else if result.exitCode != 0 || result.signal != "" {
+		cr.verdict = verdictRE
+	} else if r.memoryLimit > 0 && result.memory > r.memoryLimit {
+		cr.verdict = verdictMLE
+	} else {
+		cr.verdict = verdictAC
+	}
+	if cr.verdict != verdictAC {
+		if cr.message != "" {
+			cr.message += "\n      "
+		}
+		cr.message += fmt.Sprintf("Transcript: %v", transcriptPath)
+	}
+	return cr
+}
+
+// relay copies src to dst and keeps draining src after dst is closed so
+// that the writing process never blocks. A copy is also kept in buf.
+func relay(dst io.Writer, src io.Reader, buf *bytes.Buffer) {
+	if buf != nil {
+		src = io.TeeReader(src, buf)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		io.Copy(ioutil.Discard, src)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// processGroup is a started command together with the processes it spawns.
+type processGroup struct {
+	cmd *exec.Cmd
+}
+
+// startProcessGroup starts the command in a new process group so that
+// everything it spawns can be killed together.
+func startProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &processGroup{cmd: cmd}, nil
+}
+
+func (g *processGroup) kill() error {
+	return syscall.Kill(-g.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func (g *processGroup) release() {}
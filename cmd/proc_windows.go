@@ -0,0 +1,73 @@
+//go:build windows
+// +build windows
+
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// processGroup is a started command together with the processes it spawns.
+// They are put in a job object, since Windows has no process groups which
+// can be killed at once.
+type processGroup struct {
+	cmd *exec.Cmd
+	job windows.Handle
+}
+
+// startProcessGroup starts the command and assigns it to a new job object.
+// The processes it spawns before the assignment are not in the job, and
+// if the job cannot be created only the command itself is killed.
+func startProcessGroup(cmd *exec.Cmd) (*processGroup, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	g := &processGroup{cmd: cmd}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return g, nil
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return g, nil
+	}
+	defer windows.CloseHandle(proc)
+	if err = windows.AssignProcessToJobObject(job, proc); err != nil {
+		windows.CloseHandle(job)
+		return g, nil
+	}
+	g.job = job
+	return g, nil
+}
+
+func (g *processGroup) kill() error {
+	if g.job != 0 {
+		return windows.TerminateJobObject(g.job, 1)
+	}
+	return g.cmd.Process.Kill()
+}
+
+func (g *processGroup) release() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+		g.job = 0
+	}
+}
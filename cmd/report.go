@@ -0,0 +1,195 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	reportJSON  = "json"
+	reportJUnit = "junit"
+
+	// maxReportOutput truncates stdout and stderr in the reports.
+	maxReportOutput = 4096
+)
+
+// testReport is the machine-readable result of test.
+type testReport struct {
+	Problem   string         `json:"problem"`
+	Template  string         `json:"template"`
+	Timestamp time.Time      `json:"timestamp"`
+	Passed    bool           `json:"passed"`
+	Verdict   string         `json:"verdict,omitempty"`
+	Counts    map[string]int `json:"counts"`
+	Cases     []caseReport   `json:"cases"`
+}
+
+type caseReport struct {
+	Name     string  `json:"name"`
+	Verdict  string  `json:"verdict"`
+	Time     float64 `json:"time"`
+	CPUTime  float64 `json:"cpuTime"`
+	Memory   int64   `json:"memory"`
+	ExitCode int     `json:"exitCode"`
+	Signal   string  `json:"signal,omitempty"`
+	Message  string  `json:"message,omitempty"`
+	Stdout   string  `json:"stdout"`
+	Stderr   string  `json:"stderr"`
+}
+
+func newCaseReport(tc testCase, cr caseResult) caseReport {
+	return caseReport{
+		Name:     tc.label,
+		Verdict:  cr.verdict,
+		Time:     cr.result.elapsed.Seconds(),
+		CPUTime:  cr.result.cpuTime.Seconds(),
+		Memory:   cr.result.memory,
+		ExitCode: cr.result.exitCode,
+		Signal:   cr.result.signal,
+		Message:  cr.message,
+		Stdout:   truncateOutput(cr.result.stdout),
+		Stderr:   truncateOutput(cr.result.stderr),
+	}
+}
+
+func truncateOutput(s string) string {
+	if len(s) <= maxReportOutput {
+		return s
+	}
+	// Cut at a rune boundary so that the report stays valid UTF-8.
+	n := maxReportOutput
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n...(truncated)"
+}
+
+// defaultReportFile returns the report path in the problem directory.
+func defaultReportFile(format, quesDir string) string {
+	if format == reportJUnit {
+		return filepath.Join(quesDir, "report.xml")
+	}
+	return filepath.Join(quesDir, "report.json")
+}
+
+// writeReport writes the report to the file, or to w when the file is "-".
+func writeReport(report testReport, format, file string, w io.Writer) error {
+	if file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	switch format {
+	case reportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case reportJUnit:
+		return writeJUnit(report, w)
+	}
+	return fmt.Errorf("  [ERR] Unknown report format: %v", format)
+}
+
+type junitSuite struct {
+	XMLName   xml.Name    `xml:"testsuite"`
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit maps WA and MLE to failures, RE, TLE and CE to errors, and
+// cases without expected output to skipped tests.
+func writeJUnit(report testReport, w io.Writer) error {
+	suite := junitSuite{
+		Name:      report.Problem,
+		Timestamp: report.Timestamp.Format(time.RFC3339),
+	}
+	if report.Verdict == verdictCE {
+		suite.Errors = 1
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      "build",
+			Classname: report.Problem,
+			Error:     &junitMessage{Message: "Compilation error", Type: verdictCE},
+		})
+	}
+	for _, c := range report.Cases {
+		jc := junitCase{
+			Name:      c.Name,
+			Classname: report.Problem,
+			Time:      c.Time,
+			SystemOut: c.Stdout,
+			SystemErr: c.Stderr,
+		}
+		message := &junitMessage{Message: c.Verdict, Type: c.Verdict, Text: c.Message}
+		switch c.Verdict {
+		case verdictWA, verdictMLE:
+			jc.Failure = message
+			suite.Failures++
+		case verdictRE, verdictTLE:
+			jc.Error = message
+			suite.Errors++
+		case verdictRUN:
+			jc.Skipped = message
+			suite.Skipped++
+		}
+		suite.Time += c.Time
+		suite.Cases = append(suite.Cases, jc)
+	}
+	suite.Tests = len(suite.Cases)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -37,6 +38,7 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	rootCmd.SetArgs(hoistConfigFlag(os.Args[1:]))
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -45,6 +47,30 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: $XDG_CONFIG_HOME/pz/config.toml or ~/.config/pz/config.toml)")
+}
+
+// hoistConfigFlag moves --config in front of the subcommand. "pz config"
+// and "pz config set" stop parsing flags at their first argument so that a
+// value such as "-1" is not a flag, which would leave a trailing --config
+// as an argument.
+func hoistConfigFlag(args []string) []string {
+	var flags, rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			return append(flags, append(rest, args[i:]...)...)
+		case args[i] == "--config" && i+1 < len(args):
+			flags = append(flags, args[i], args[i+1])
+			i++
+		case strings.HasPrefix(args[i], "--config="):
+			flags = append(flags, args[i])
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return append(flags, rest...)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -26,24 +26,53 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"path/filepath"
 
-	"github.com/BurntSushi/toml"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/atotto/clipboard"
 	"github.com/mgutz/ansi"
 	"github.com/sclevine/agouti"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
 	stdoutColor = "green"
 	stderrColor = "red"
+	tleColor    = "yellow"
+	reColor     = "magenta"
+	mleColor    = "yellow"
+	runColor    = "cyan"
+
+	// defaultTimeout is the time limit (seconds) used when neither
+	// config.toml nor template.toml sets one.
+	defaultTimeout = 10.0
+
+	// stderrTailLines is the number of stderr lines shown on a runtime error.
+	stderrTailLines = 10
+)
+
+const (
+	verdictAC  = "AC"
+	verdictWA  = "WA"
+	verdictRE  = "RE"
+	verdictTLE = "TLE"
+	verdictMLE = "MLE"
+	// verdictRUN is given to a case without expected output.
+	verdictRUN = "RUN"
+	// verdictCE is given to the whole test run when the build fails.
+	verdictCE = "CE"
 )
 
+// verdicts lists the verdicts in the order of the summary.
+var verdicts = []string{verdictAC, verdictWA, verdictRE, verdictTLE, verdictMLE, verdictRUN}
+
+var runBrowser browserOptions
+
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:   "run",
@@ -68,58 +97,86 @@ var runCmd = &cobra.Command{
 		if f, err := os.Stat(tempDir); os.IsNotExist(err) || !f.IsDir() {
 			log.Fatalf("[ERR] Template: %v is not found\n", args[0])
 		}
-		if err := run(temp); err != nil {
+		if err := run(temp, runBrowser); err != nil {
 			log.Fatalln(err)
 		}
 	},
 }
 
-func run(temp string) error {
-	url := "https://paiza.jp/sign_in"
-	driver := agouti.ChromeDriver()
-	if err := driver.Start(); err != nil {
+func run(temp string, opts browserOptions) error {
+	// Migrate before the session check, which usually skips login.
+	if err := migratePassword(); err != nil {
+		log.Printf("%v", err)
 		return err
 	}
-	defer driver.Stop()
-
-	page, err := driver.NewPage(agouti.Browser("chrome"))
+	page, closePage, err := newPage(opts)
 	if err != nil {
 		log.Printf("%v", err)
 		return err
 	}
+	defer closePage()
 
-	if err = page.Navigate(url); err != nil {
+	valid, err := restoreSession(page)
+	if err != nil {
 		log.Printf("%v", err)
 		return err
 	}
 
-	if err = login(page); err != nil {
-		log.Printf("%v", err)
-		return err
+	if !valid {
+		if err = login(page); err != nil {
+			log.Printf("%v", err)
+			return err
+		}
 	}
 
 	if err = interactive(page, temp); err != nil {
 		return err
 	}
+	// Keep the session even when the user logged in by hand.
+	if valid, err := loggedIn(page); err == nil && valid {
+		if err := saveSession(page); err != nil {
+			log.Printf("%v", err)
+		}
+	}
 	return nil
 }
 
+// login fills the sign in form, and saves the session cookies when it
+// succeeds.
 func login(p *agouti.Page) error {
 	config, err := getConfig()
 	if err != nil {
 		return err
 	}
+	if config.User.Email == "" {
+		return nil
+	}
+	password, err := getPassword(config)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return nil
+	}
 	email := p.FindByID("email")
 	pass := p.FindByID("password")
 
 	email.Fill(config.User.Email)
-	pass.Fill(config.User.Pass)
+	pass.Fill(password)
 
 	if err := p.FirstByClass("a-button-primary-large").Submit(); err != nil {
 		return err
 	}
 
-	return nil
+	valid, err := waitLoggedIn(p, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		fmt.Println("  [ERR] Could not log in. Log in on the browser.")
+		return nil
+	}
+	return saveSession(p)
 }
 
 func interactive(p *agouti.Page, temp string) error {
@@ -132,32 +189,103 @@ func interactive(p *agouti.Page, temp string) error {
 			if err := get(p, temp); err != nil {
 				fmt.Println(err)
 			}
-		} else if text == "test" || text == "t" {
-			quesID, _, _, err := getQuestion(p)
+		} else if textSplit[0] == "test" || textSplit[0] == "t" {
+			quesDir, err := getQuesDirFromPage(p)
 			if err != nil {
 				fmt.Println(err)
 			} else {
-				if err := test(temp, quesID); err != nil {
+				opts, err := parseTestOptions(textSplit[1:])
+				if err != nil {
+					fmt.Println(err)
+				} else if _, err := test(temp, quesDir, opts); err != nil {
 					fmt.Println(err)
 				}
 			}
 		} else if textSplit[0] == "debug" || textSplit[0] == "d" {
-			quesID, _, _, err := getQuestion(p)
+			quesDir, err := getQuesDirFromPage(p)
 			if err != nil {
 				fmt.Println(err)
 			} else {
 				if len(textSplit) != 2 {
 					fmt.Println("  [ERR] Select a number that you want to debug.")
+				} else if textSplit[1] == "-" || textSplit[1] == "-e" {
+					if _, err := debugAdHoc(temp, quesDir, stdin, textSplit[1] == "-e"); err != nil {
+						fmt.Println(err)
+					}
 				} else {
 					programID, err := strconv.Atoi(textSplit[1])
 					if err != nil {
 						fmt.Println(err)
+					} else if _, err := debug(temp, quesDir, programID); err != nil {
+						fmt.Println(err)
 					}
-					if err := debug(temp, quesID, programID); err != nil {
+				}
+			}
+		} else if textSplit[0] == "dbg" {
+			quesDir, err := getQuesDirFromPage(p)
+			if err != nil {
+				fmt.Println(err)
+			} else if len(textSplit) != 2 {
+				fmt.Println("  [ERR] Select a number that you want to debug.")
+			} else if programID, err := strconv.Atoi(textSplit[1]); err != nil {
+				fmt.Println(err)
+			} else if err := runDebugger(temp, quesDir, programID); err != nil {
+				fmt.Println(err)
+			}
+		} else if text == "addcase" {
+			quesDir, err := getQuesDirFromPage(p)
+			if err != nil {
+				fmt.Println(err)
+			} else if err := addCase(quesDir); err != nil {
+				fmt.Println(err)
+			}
+		} else if textSplit[0] == "editcase" || textSplit[0] == "rmcase" {
+			quesDir, err := getQuesDirFromPage(p)
+			if err != nil {
+				fmt.Println(err)
+			} else if len(textSplit) != 2 {
+				fmt.Println("  [ERR] Select a number of the custom case.")
+			} else {
+				caseID, err := strconv.Atoi(textSplit[1])
+				if err != nil {
+					fmt.Println(err)
+				} else if textSplit[0] == "editcase" {
+					if err := editCase(quesDir, caseID); err != nil {
+						fmt.Println(err)
+					}
+				} else {
+					if err := removeCase(quesDir, caseID); err != nil {
 						fmt.Println(err)
 					}
 				}
 			}
+		} else if textSplit[0] == "stress" {
+			quesDir, err := getQuesDirFromPage(p)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				opts := stressOptions{count: defaultStressCount, seed: 1}
+				if len(textSplit) == 2 {
+					opts.count, err = strconv.Atoi(textSplit[1])
+				}
+				if err != nil {
+					fmt.Println(err)
+				} else if _, err := stress(temp, quesDir, opts); err != nil {
+					fmt.Println(err)
+				}
+			}
+		} else if textSplit[0] == "watch" || textSplit[0] == "w" {
+			quesDir, err := getQuesDirFromPage(p)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				opts, err := parseTestOptions(textSplit[1:])
+				if err != nil {
+					fmt.Println(err)
+				} else if err := watch(temp, quesDir, opts); err != nil {
+					fmt.Println(err)
+				}
+			}
 		} else if text == "submit" || text == "s" {
 			quesID, _, _, err := getQuestion(p)
 			if err != nil {
@@ -221,6 +349,18 @@ func get(p *agouti.Page, temp string) error {
 	return nil
 }
 
+// resolveTemplate returns the template name, or defaultTemplate when it is empty.
+func resolveTemplate(temp string) (string, error) {
+	if temp != "" {
+		return temp, nil
+	}
+	config, err := getConfig()
+	if err != nil {
+		return "", err
+	}
+	return config.Setting.DefalutTemp, nil
+}
+
 func getTemplateConfig(temp string) (templateConfig, string, error) {
 	configDir, err := getConfigDir()
 	if err != nil {
@@ -230,9 +370,11 @@ func getTemplateConfig(temp string) (templateConfig, string, error) {
 	tempConfigPath := filepath.Join(tempDir, "template.toml")
 
 	var tempConfig templateConfig
-	if _, err = toml.DecodeFile(tempConfigPath, &tempConfig); err != nil {
+	unknown, err := decodeFile(tempConfigPath, &tempConfig)
+	if err != nil {
 		return templateConfig{}, "", err
 	}
+	warnUnknownKeys(tempConfigPath, unknown)
 	return tempConfig, tempDir, nil
 }
 
@@ -310,69 +452,336 @@ func writeFile(filename string, content string) error {
 	return nil
 }
 
-func runCommand(cmd *exec.Cmd, verbose bool) (stdout, stderr string, exitCode int, err error) {
-	outReader, err := cmd.StdoutPipe()
+// runResult is the outcome of a program execution.
+type runResult struct {
+	stdout   string
+	stderr   string
+	exitCode int
+	signal   string
+	timedOut bool
+	elapsed  time.Duration
+	cpuTime  time.Duration
+	memory   int64
+}
+
+// pipeDrainTimeout is how long the output is still read after the command
+// exits, since the processes it spawned may keep the pipes open.
+const pipeDrainTimeout = time.Second
+
+func runCommand(cmd *exec.Cmd, verbose bool, timeout time.Duration) (result runResult, err error) {
+	// The pipes are created here rather than by exec, whose Wait does not
+	// return until every process holding them has exited.
+	outReader, outWriter, err := os.Pipe()
 	if err != nil {
 		return
 	}
-	errReader, err := cmd.StderrPipe()
+	defer outReader.Close()
+	errReader, errWriter, err := os.Pipe()
 	if err != nil {
+		outWriter.Close()
 		return
 	}
+	defer errReader.Close()
+	cmd.Stdout = outWriter
+	cmd.Stderr = errWriter
 
 	var bufout, buferr bytes.Buffer
 	outReader2 := io.TeeReader(outReader, &bufout)
 	errReader2 := io.TeeReader(errReader, &buferr)
 
-	if err = cmd.Start(); err != nil {
+	start := time.Now()
+	group, err := startProcessGroup(cmd)
+	outWriter.Close()
+	errWriter.Close()
+	if err != nil {
 		return
 	}
+	defer group.release()
 
-	go printOutputWithHeader("    >> ", stdoutColor, outReader2, verbose)
-	go printOutputWithHeader("    >> ", stderrColor, errReader2, verbose)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		printOutputWithHeader(">> ", stdoutColor, outReader2, verbose, start)
+	}()
+	go func() {
+		defer wg.Done()
+		printOutputWithHeader("!! ", stderrColor, errReader2, verbose, start)
+	}()
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		select {
+		case err = <-done:
+			timer.Stop()
+		case <-timer.C:
+			result.timedOut = true
+			group.kill()
+			err = <-done
+		}
+	} else {
+		err = <-done
+	}
+	result.elapsed = time.Since(start)
 
-	err = cmd.Wait()
+	// Whatever still holds the pipes is killed, and the read ends are
+	// closed for the processes which left the group.
+	select {
+	case <-drained:
+	case <-time.After(pipeDrainTimeout):
+		group.kill()
+		outReader.Close()
+		errReader.Close()
+		<-drained
+	}
 
-	stdout = bufout.String()
-	stderr = buferr.String()
+	result.stdout = bufout.String()
+	result.stderr = buferr.String()
+	if cmd.ProcessState != nil {
+		result.cpuTime = cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+		result.memory = peakMemory(cmd.ProcessState)
+	}
 
 	if err != nil {
 		if err2, ok := err.(*exec.ExitError); ok {
 			if s, ok := err2.Sys().(syscall.WaitStatus); ok {
 				err = nil
-				exitCode = s.ExitStatus()
+				result.exitCode = s.ExitStatus()
+				if s.Signaled() {
+					result.signal = s.Signal().String()
+				}
 			}
 		}
 	}
 	return
 }
 
-func printOutputWithHeader(header, color string, r io.Reader, verbose bool) {
+// outputMu serializes the lines of stdout and stderr so that they are
+// printed in the order they arrive.
+var outputMu sync.Mutex
+
+func printOutputWithHeader(header, color string, r io.Reader, verbose bool, start time.Time) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		if verbose {
-			fmt.Printf("%s%s\n", header, ansi.Color(scanner.Text(), color))
+			outputMu.Lock()
+			elapsed := time.Since(start).Seconds()
+			fmt.Printf("    [%8.3fs] %s%s\n", elapsed, header, ansi.Color(scanner.Text(), color))
+			outputMu.Unlock()
 		}
 	}
+	// keep reading after an overlong line so that the program never blocks
+	io.Copy(ioutil.Discard, r)
 }
 
-func test(temp, quesID string) error {
-	if temp == "" {
-		return fmt.Errorf("  [ERR] Select a template.")
+// testOptions controls how the test cases are run.
+type testOptions struct {
+	jobs          int
+	preciseTiming bool
+	// compact omits the details of the accepted cases.
+	compact bool
+	// report is the format of the report (json or junit), and reportFile
+	// is its path or "-" for stdout.
+	report     string
+	reportFile string
+}
+
+// addTestFlags defines the options of test for the REPL and `pz test`.
+func addTestFlags(flags *pflag.FlagSet, opts *testOptions) {
+	flags.IntVarP(&opts.jobs, "jobs", "j", 0, "number of test cases run in parallel")
+	flags.BoolVar(&opts.preciseTiming, "precise-timing", false, "run the test cases one by one for accurate timing")
+	flags.StringVar(&opts.report, "report", "", "write a report: json or junit")
+	flags.StringVar(&opts.reportFile, "report-file", "", "path of the report, or - for stdout (default: report.json/report.xml in the question directory)")
+}
+
+func parseTestOptions(args []string) (testOptions, error) {
+	var opts testOptions
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addTestFlags(flags, &opts)
+	if err := flags.Parse(args); err != nil {
+		return testOptions{}, fmt.Errorf("  [ERR] %v", err)
 	}
+	return opts, nil
+}
+
+// testRunner holds what is shared by all the test cases of a problem.
+type testRunner struct {
+	tempConfig  templateConfig
+	probConfig  problemConfig
+	cmdCtx      commandContext
+	timeout     time.Duration
+	memoryLimit int64
+	check       checker
+}
+
+func newTestRunner(temp, quesDir string) (testRunner, error) {
 	tempConfig, _, err := getTemplateConfig(temp)
 	if err != nil {
-		return err
+		return testRunner{}, err
 	}
-	quesDir, err := getQuesDir(quesID)
+	cmdCtx := newCommandContext(tempConfig, quesDir)
+	timeout, err := getTimeout(tempConfig)
 	if err != nil {
-		return err
+		return testRunner{}, err
 	}
-	programPath := filepath.Join(quesDir, tempConfig.File)
-	cmdStrings := strings.Replace(tempConfig.Run, tempConfig.File, programPath, 1)
+	memoryLimit, err := getMemoryLimit(tempConfig)
 	if err != nil {
-		return err
+		return testRunner{}, err
+	}
+	probConfig, err := getProblemConfig(quesDir)
+	if err != nil {
+		return testRunner{}, err
+	}
+	check, err := newChecker(mergeChecker(tempConfig.checkerConfig, probConfig.checkerConfig), cmdCtx)
+	if err != nil {
+		return testRunner{}, err
+	}
+	return testRunner{
+		tempConfig:  tempConfig,
+		probConfig:  probConfig,
+		cmdCtx:      cmdCtx,
+		timeout:     timeout,
+		memoryLimit: memoryLimit,
+		check:       check,
+	}, nil
+}
+
+// caseResult is the judged outcome of a test case.
+type caseResult struct {
+	verdict  string
+	result   runResult
+	message  string
+	expected string
+	// interactive is set when the case is judged by an interactor.
+	interactive bool
+	err         error
+}
+
+// test runs all the test cases of the problem and reports whether every
+// judged case is accepted.
+func test(temp, quesDir string, opts testOptions) (bool, error) {
+	if temp == "" {
+		return false, fmt.Errorf("  [ERR] Select a template.")
+	}
+	if opts.report != "" && opts.report != reportJSON && opts.report != reportJUnit {
+		return false, fmt.Errorf("  [ERR] Unknown report format: %v", opts.report)
+	}
+	// keep stdout for the report and print the progress to stderr
+	out := io.Writer(os.Stdout)
+	if opts.report != "" && opts.reportFile == "-" {
+		out = os.Stderr
 	}
+	report := testReport{
+		Problem:   filepath.Base(quesDir),
+		Template:  temp,
+		Timestamp: time.Now(),
+		Counts:    map[string]int{},
+		Cases:     []caseReport{},
+	}
+	finish := func(passed bool) (bool, error) {
+		if opts.report == "" {
+			return passed, nil
+		}
+		report.Passed = passed
+		file := opts.reportFile
+		if file == "" {
+			file = defaultReportFile(opts.report, quesDir)
+		}
+		if err := writeReport(report, opts.report, file, os.Stdout); err != nil {
+			return passed, err
+		}
+		if file != "-" {
+			fmt.Fprintf(out, "  Report: %v\n", file)
+		}
+		return passed, nil
+	}
+	runner, err := newTestRunner(temp, quesDir)
+	if err != nil {
+		return false, err
+	}
+	config, err := getConfig()
+	if err != nil {
+		return false, err
+	}
+	cases, err := listCases(quesDir)
+	if err != nil {
+		return false, err
+	}
+	sampleSize := len(cases)
+	built, err := buildProgram(runner.tempConfig, runner.cmdCtx, out)
+	if err != nil {
+		return false, err
+	}
+	if !built {
+		fmt.Fprintf(out, "  [Result] Pass 0/%v (%v)\n", sampleSize, ansi.Color("Compilation error", ceColor))
+		report.Verdict = verdictCE
+		return finish(false)
+	}
+
+	jobs := opts.jobs
+	if jobs <= 0 {
+		jobs = config.Setting.Jobs
+	}
+	if jobs <= 0 || opts.preciseTiming {
+		jobs = 1
+	}
+	results := runCases(runner, cases, jobs)
+	defer results.stop()
+
+	counts := map[string]int{}
+	var maxUsage runResult
+	for i := 0; i < sampleSize; i++ {
+		cr := <-results.cases[i]
+		if cr.err != nil {
+			return false, cr.err
+		}
+		counts[cr.verdict]++
+		report.Cases = append(report.Cases, newCaseReport(cases[i], cr))
+		maxUsage = maxRunUsage(maxUsage, cr.result)
+		if opts.compact && cr.verdict == verdictAC {
+			continue
+		}
+		fmt.Fprintf(out, "  Test: %v\n", cases[i].label)
+		runner.printCase(cr, config.Setting, out)
+	}
+	// cases without expected output are not judged
+	judged := sampleSize - counts[verdictRUN]
+	count := counts[verdictAC]
+	var t string
+	if count == judged {
+		t = ansi.Color("All clear!", "green")
+	} else {
+		t = ansi.Color(fmt.Sprintf("%v failed...", judged-count), "red")
+	}
+	fmt.Fprintf(out, "  [Result] Pass %v/%v (%v) %v\n", count, judged, t, formatCounts(counts))
+	if sampleSize > 0 {
+		fmt.Fprintf(out, "  [Max] %v\n", formatUsage(maxUsage))
+	}
+	for _, v := range verdicts {
+		report.Counts[v] = counts[v]
+	}
+	return finish(count == judged)
+}
+
+// testCase is a pair of input and expected output files.
+type testCase struct {
+	label      string
+	inputPath  string
+	outputPath string
+}
+
+// listCases returns the sample cases in tests/ followed by the custom
+// cases in tests/custom/.
+func listCases(quesDir string) ([]testCase, error) {
 	testDir := filepath.Join(quesDir, "tests")
 	files, err := ioutil.ReadDir(testDir)
 	sampleSize := 0
@@ -381,7 +790,7 @@ func test(temp, quesID string) error {
 			s := strings.Replace(strings.Replace(file.Name(), "input_", "", 1), ".txt", "", 1)
 			n, err := strconv.Atoi(s)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			n++
 			if n > sampleSize {
@@ -389,62 +798,304 @@ func test(temp, quesID string) error {
 			}
 		}
 	}
-	count := 0
+	cases := []testCase{}
 	for i := 0; i < sampleSize; i++ {
-		fmt.Printf("  Test: case %v\n", i+1)
-		inputPath := filepath.Join(testDir, fmt.Sprintf("input_%v.txt", i))
-		input, err := ioutil.ReadFile(inputPath)
-		if err != nil {
-			return err
+		cases = append(cases, testCase{
+			label:      fmt.Sprintf("case %v", i+1),
+			inputPath:  filepath.Join(testDir, fmt.Sprintf("input_%v.txt", i)),
+			outputPath: filepath.Join(testDir, fmt.Sprintf("output_%v.txt", i)),
+		})
+	}
+	customSize, err := countCustomCases(quesDir)
+	if err != nil {
+		return nil, err
+	}
+	customDir := getCustomDir(quesDir)
+	for i := 0; i < customSize; i++ {
+		cases = append(cases, testCase{
+			label:      fmt.Sprintf("custom %v", i+1),
+			inputPath:  filepath.Join(customDir, fmt.Sprintf("input_%v.txt", i)),
+			outputPath: filepath.Join(customDir, fmt.Sprintf("output_%v.txt", i)),
+		})
+	}
+	return cases, nil
+}
+
+// caseResults delivers the result of each test case through its own channel
+// so that they can be printed in case order.
+type caseResults struct {
+	cases []chan caseResult
+	quit  chan struct{}
+}
+
+func (r caseResults) stop() {
+	close(r.quit)
+}
+
+// runCases runs the test cases with a pool of jobs workers.
+func runCases(runner testRunner, cases []testCase, jobs int) caseResults {
+	size := len(cases)
+	results := caseResults{
+		cases: make([]chan caseResult, size),
+		quit:  make(chan struct{}),
+	}
+	for i := range results.cases {
+		results.cases[i] = make(chan caseResult, 1)
+	}
+	queue := make(chan int)
+	go func() {
+		defer close(queue)
+		for i := 0; i < size; i++ {
+			select {
+			case queue <- i:
+			case <-results.quit:
+				return
+			}
 		}
-		cmd, err := convCmd(cmdStrings)
-		cmd.Stdin = bytes.NewBufferString(string(input))
-		start := time.Now()
-		stdout, _, _, err := runCommand(cmd, false)
-		if err != nil {
-			return err
+	}()
+	for w := 0; w < jobs; w++ {
+		go func() {
+			for i := range queue {
+				results.cases[i] <- runner.runCase(cases[i])
+			}
+		}()
+	}
+	return results
+}
+
+func (r testRunner) runCase(tc testCase) caseResult {
+	if r.probConfig.Interactor != "" {
+		return r.runInteractive(tc)
+	}
+	input, err := ioutil.ReadFile(tc.inputPath)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	cmd, err := r.cmdCtx.command(r.tempConfig.Run)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	cmd.Stdin = bytes.NewBufferString(string(input))
+	result, err := runCommand(cmd, false, r.timeout)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	if result.timedOut {
+		return caseResult{verdict: verdictTLE, result: result}
+	}
+	if result.exitCode != 0 || result.signal != "" {
+		return caseResult{verdict: verdictRE, result: result}
+	}
+	if r.memoryLimit > 0 && result.memory > r.memoryLimit {
+		return caseResult{verdict: verdictMLE, result: result}
+	}
+	if !Exists(tc.outputPath) {
+		return caseResult{verdict: verdictRUN, result: result}
+	}
+	output, err := ioutil.ReadFile(tc.outputPath)
+	if err != nil {
+		return caseResult{err: err}
+	}
+	passed, message, err := r.check(checkCase{
+		inputPath:  tc.inputPath,
+		outputPath: tc.outputPath,
+		expected:   string(output),
+		actual:     result.stdout,
+	})
+	if err != nil {
+		return caseResult{err: err}
+	}
+	cr := caseResult{verdict: verdictWA, result: result, message: message, expected: string(output)}
+	if passed {
+		cr.verdict = verdictAC
+	}
+	return cr
+}
+
+func (r testRunner) printCase(cr caseResult, setting SettingConfig, w io.Writer) {
+	result := cr.result
+	switch cr.verdict {
+	case verdictTLE:
+		outText := fmt.Sprintf("    [TLE] Time limit exceeded. (time:%vs, limit:%vs)", result.elapsed.Seconds(), r.timeout.Seconds())
+		fmt.Fprintln(w, ansi.Color(outText, tleColor))
+	case verdictRE:
+		printRuntimeError("    ", result, w)
+	case verdictMLE:
+		outText := fmt.Sprintf("    [MLE] Memory limit exceeded. (%v, limit:%v)", formatUsage(result), formatMemory(r.memoryLimit))
+		fmt.Fprintln(w, ansi.Color(outText, mleColor))
+	case verdictRUN:
+		outText := fmt.Sprintf("    [RUN] No expected output. (%v)", formatUsage(result))
+		fmt.Fprintln(w, ansi.Color(outText, runColor))
+		printOutput("      ", result, w)
+	case verdictAC:
+		outText := fmt.Sprintf("    [Success] Passed the test. (%v)", formatUsage(result))
+		fmt.Fprintln(w, ansi.Color(outText, "green"))
+		if cr.message != "" {
+			fmt.Fprintf(w, "      %v\n", cr.message)
 		}
-		end := time.Now()
-		outputPath := filepath.Join(testDir, fmt.Sprintf("output_%v.txt", i))
-		output, err := ioutil.ReadFile(outputPath)
-		if err != nil {
-			return err
+	case verdictWA:
+		outText := fmt.Sprintf("    [Falure] Did not pass the test. (%v)", formatUsage(result))
+		fmt.Fprintln(w, ansi.Color(outText, "red"))
+		if cr.message != "" {
+			fmt.Fprintf(w, "      %v\n", cr.message)
 		}
-		outputList := strings.Split(string(output), "\n")
-		stdoutList := strings.Split(strings.TrimSpace(stdout), "\n")
-		check := true
-		if len(outputList) != len(stdoutList) {
-			check = false
-		} else {
-			for i := 0; i < len(outputList); i++ {
-				if outputList[i] != stdoutList[i] {
-					check = false
-				}
-			}
+		if !cr.interactive {
+			printDiff(normalizeLines(cr.expected), normalizeLines(result.stdout), setting.DiffStyle, setting.DiffLines, w)
 		}
-		var outText string
-		if check {
-			outText = fmt.Sprintf("    [Success] Passed the test. (time:%vs)", (end.Sub(start)).Seconds())
-			outText = ansi.Color(outText, "green")
-			count++
-		} else {
-			outText = "    [Falure] Did not pass the test."
-			outText = ansi.Color(outText, "red")
+	}
+}
+
+// maxRunUsage keeps the largest time and memory of two runs.
+func maxRunUsage(a, b runResult) runResult {
+	if b.elapsed > a.elapsed {
+		a.elapsed = b.elapsed
+	}
+	if b.cpuTime > a.cpuTime {
+		a.cpuTime = b.cpuTime
+	}
+	if b.memory > a.memory {
+		a.memory = b.memory
+	}
+	return a
+}
+
+func formatUsage(result runResult) string {
+	text := fmt.Sprintf("time:%vs, cpu:%vs", result.elapsed.Seconds(), result.cpuTime.Seconds())
+	if result.memory > 0 {
+		text += fmt.Sprintf(", memory:%v", formatMemory(result.memory))
+	}
+	return text
+}
+
+func formatMemory(bytes int64) string {
+	return fmt.Sprintf("%.1fMB", float64(bytes)/(1024*1024))
+}
+
+// formatCounts summarizes the number of cases per verdict.
+func formatCounts(counts map[string]int) string {
+	list := []string{}
+	for _, v := range verdicts {
+		list = append(list, fmt.Sprintf("%v:%v", v, counts[v]))
+	}
+	return strings.Join(list, " ")
+}
+
+// printOutput prints the stdout and stderr of a run.
+func printOutput(indent string, result runResult, w io.Writer) {
+	for _, out := range []struct {
+		text  string
+		color string
+	}{{result.stdout, stdoutColor}, {result.stderr, stderrColor}} {
+		if out.text == "" {
+			continue
+		}
+		for _, l := range strings.Split(strings.TrimRight(out.text, "\n"), "\n") {
+			fmt.Fprintf(w, "%v>> %v\n", indent, ansi.Color(l, out.color))
 		}
-		fmt.Println(outText)
 	}
-	var t string
-	if count == sampleSize {
-		t = ansi.Color("All clear!", "green")
+}
+
+// printRuntimeError reports a crashed program with the tail of its stderr.
+func printRuntimeError(indent string, result runResult, w io.Writer) {
+	var cause string
+	if result.signal != "" {
+		cause = fmt.Sprintf("signal:%v", result.signal)
 	} else {
-		t = ansi.Color(fmt.Sprintf("%v failed...", sampleSize-count), "red")
+		cause = fmt.Sprintf("exit code:%v", result.exitCode)
+	}
+	outText := fmt.Sprintf("%v[RE] Runtime error. (%v, %v)", indent, cause, formatUsage(result))
+	fmt.Fprintln(w, ansi.Color(outText, reColor))
+	lines := strings.Split(strings.TrimRight(result.stderr, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return
+	}
+	if len(lines) > stderrTailLines {
+		fmt.Fprintf(w, "%v  ... (%v lines omitted)\n", indent, len(lines)-stderrTailLines)
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+	for _, l := range lines {
+		fmt.Fprintf(w, "%v  >> %v\n", indent, ansi.Color(l, stderrColor))
 	}
-	fmt.Printf("  [Result] Pass %v/%v (%v)\n", count, sampleSize, t)
-	return nil
+}
+
+// getTimeout returns the time limit of a test case. The template setting
+// takes precedence over the global one, and a negative value disables it.
+func getTimeout(tempConfig templateConfig) (time.Duration, error) {
+	config, err := getConfig()
+	if err != nil {
+		return 0, err
+	}
+	limit := defaultTimeout
+	if tempConfig.Timeout != 0 {
+		limit = float64(tempConfig.Timeout)
+	} else if config.Setting.Timeout != 0 {
+		limit = float64(config.Setting.Timeout)
+	}
+	if limit < 0 {
+		return 0, nil
+	}
+	return time.Duration(limit * float64(time.Second)), nil
+}
+
+// getMemoryLimit returns the memory limit in bytes, or 0 when it is not set.
+// The template setting takes precedence over the global one.
+func getMemoryLimit(tempConfig templateConfig) (int64, error) {
+	config, err := getConfig()
+	if err != nil {
+		return 0, err
+	}
+	limit := config.Setting.MemoryLimit
+	if tempConfig.MemoryLimit != 0 {
+		limit = tempConfig.MemoryLimit
+	}
+	if limit < 0 {
+		return 0, nil
+	}
+	return int64(float64(limit) * 1024 * 1024), nil
+}
+
+// getQuesDirFromPage returns the directory of the question shown in the browser.
+func getQuesDirFromPage(p *agouti.Page) (string, error) {
+	quesID, _, _, err := getQuestion(p)
+	if err != nil {
+		return "", err
+	}
+	return getQuesDir(quesID)
+}
+
+// resolveQuesDir finds a question directory from a path or an ID for the
+// commands run without a browser. An empty arg means the current directory.
+func resolveQuesDir(arg string) (string, error) {
+	if arg == "" {
+		arg = "."
+	}
+	if f, err := os.Stat(arg); err == nil && f.IsDir() {
+		dir, err := filepath.Abs(arg)
+		if err != nil {
+			return "", err
+		}
+		if f, err := os.Stat(filepath.Join(dir, "tests")); err == nil && f.IsDir() {
+			return dir, nil
+		}
+		// <rank>/<ID> layout made by get
+		base := filepath.Base(dir)
+		if base != "" && filepath.Base(filepath.Dir(dir)) == string([]rune(base)[0]) {
+			return dir, nil
+		}
+		return "", fmt.Errorf("  [ERR] Not a question directory: %v", dir)
+	}
+	quesDir, err := getQuesDir(arg)
+	if err != nil {
+		return "", err
+	}
+	if f, err := os.Stat(quesDir); os.IsNotExist(err) || !f.IsDir() {
+		return "", fmt.Errorf("  [ERR] Could not find a question: %v", arg)
+	}
+	return quesDir, nil
 }
 
 func getQuesDir(quesID string) (string, error) {
-	pwd, err := os.Getwd()
+	pwd, err := getWorkspace()
 	if err != nil {
 		return "", err
 	}
@@ -453,17 +1104,17 @@ func getQuesDir(quesID string) (string, error) {
 	return quesDir, nil
 }
 
-func convCmd(cmdText string) (*exec.Cmd, error) {
-	cmdStrings := strings.Split(cmdText, " ")
-	var cmd *exec.Cmd
-	if len(cmdStrings) == 0 {
-		return cmd, fmt.Errorf("  [ERR] Set a execution command to template.toml")
-	} else if len(cmdStrings) == 1 {
-		cmd = exec.Command(cmdStrings[0])
-	} else {
-		cmd = exec.Command(cmdStrings[0], cmdStrings[1:]...)
+// getWorkspace returns the directory holding the <rank>/<ID> directories:
+// $PZ_WORKSPACE, the workspace setting or the current directory.
+func getWorkspace() (string, error) {
+	config, err := getConfig()
+	if err != nil {
+		return "", err
 	}
-	return cmd, nil
+	if config.Setting.Workspace != "" {
+		return filepath.Abs(config.Setting.Workspace)
+	}
+	return os.Getwd()
 }
 
 func submit(p *agouti.Page, temp, quesID string) error {
@@ -495,60 +1146,10 @@ func submit(p *agouti.Page, temp, quesID string) error {
 	return nil
 }
 
-func debug(temp, quesID string, programID int) error {
-	if temp == "" {
-		return fmt.Errorf("  [ERR] Select a template.")
-	}
-	tempConfig, _, err := getTemplateConfig(temp)
-	if err != nil {
-		return err
-	}
-	quesDir, err := getQuesDir(quesID)
-	if err != nil {
-		return err
-	}
-	programPath := filepath.Join(quesDir, tempConfig.File)
-	cmdStrings := strings.Replace(tempConfig.Run, tempConfig.File, programPath, 1)
-	if err != nil {
-		return err
-	}
-	testDir := filepath.Join(quesDir, "tests")
-	files, err := ioutil.ReadDir(testDir)
-	sampleSize := 0
-	for _, file := range files {
-		if strings.Contains(file.Name(), "input_") && strings.Contains(file.Name(), ".txt") {
-			s := strings.Replace(strings.Replace(file.Name(), "input_", "", 1), ".txt", "", 1)
-			n, err := strconv.Atoi(s)
-			if err != nil {
-				return err
-			}
-			n++
-			if n > sampleSize {
-				sampleSize = n
-			}
-		}
-	}
-	if 0 <= programID-1 && programID <= sampleSize {
-		fmt.Printf("  Debug: case %v\n", programID)
-		inputPath := filepath.Join(testDir, fmt.Sprintf("input_%v.txt", programID-1))
-		input, err := ioutil.ReadFile(inputPath)
-		if err != nil {
-			return err
-		}
-		cmd, err := convCmd(cmdStrings)
-		cmd.Stdin = bytes.NewBufferString(string(input))
-		start := time.Now()
-		_, _, _, err = runCommand(cmd, true)
-		if err != nil {
-			return err
-		}
-		end := time.Now()
-		fmt.Printf("  [Finish] time:%vs\n", (end.Sub(start)).Seconds())
-	} else {
-		return fmt.Errorf("  [ERR] Could not find a sample: %v", programID)
-	}
-	return nil
-}
 func init() {
 	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().BoolVar(&runBrowser.headless, "headless", false, "run Chrome without a window")
+	runCmd.Flags().StringVar(&runBrowser.debuggerAddress, "attach", "", "attach to a running Chrome at the remote-debugging address (host:port)")
+	runCmd.Flags().StringVar(&runBrowser.remoteURL, "remote", "", "use a remote WebDriver server at the URL")
 }
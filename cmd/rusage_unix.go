@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns the peak resident set size of a finished process in bytes.
+// The rusage from wait4 also covers the descendants the process waited for,
+// but as the largest of them rather than the sum, and it misses those left
+// running in the background. RUSAGE_CHILDREN is not used since it is shared
+// by the cases running in parallel.
+func peakMemory(state *os.ProcessState) int64 {
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	// ru_maxrss is in bytes on macOS and in kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss)
+	}
+	return int64(usage.Maxrss) * 1024
+}
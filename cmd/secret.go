@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	passwordStoreKeyring = "keyring"
+	passwordStoreFile    = "file"
+
+	// keyringService and keyringAccount identify the password in the keyring.
+	keyringService = "pz"
+	keyringAccount = "paiza"
+
+	passwordMask = "********"
+)
+
+// getPassword returns the password from the first configured source:
+// $PZ_PASSWORD, passwordCommand, passwordEnv, passwordStore, then the
+// plaintext password left in config.toml.
+func getPassword(config Config) (string, error) {
+	if v := os.Getenv(envPassword); v != "" {
+		return v, nil
+	}
+	user := config.User
+	if user.PasswordCommand != "" {
+		words, err := splitWords(user.PasswordCommand)
+		if err != nil {
+			return "", err
+		}
+		if len(words) == 0 {
+			return "", fmt.Errorf("  [ERR] Set a command to passwordCommand")
+		}
+		cmd := exec.Command(words[0], words[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("  [ERR] passwordCommand failed: %v", err)
+		}
+		// Like git credential helpers, only the first line is the password.
+		return strings.SplitN(string(out), "\n", 2)[0], nil
+	}
+	if user.PasswordEnv != "" {
+		return os.Getenv(user.PasswordEnv), nil
+	}
+	switch user.PasswordStore {
+	case passwordStoreKeyring:
+		return keyringGet()
+	case passwordStoreFile:
+		return passwordFileGet()
+	case "":
+	default:
+		return "", fmt.Errorf("  [ERR] Unknown passwordStore: %v (keyring or file)", user.PasswordStore)
+	}
+	return user.Pass, nil
+}
+
+// migratePassword moves a plaintext password in config.toml to a secret
+// store and rewrites the config. The password is kept in plaintext with a
+// warning when no store can be used.
+func migratePassword() error {
+	fileConfig, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if fileConfig.User.Pass == "" || fileConfig.User.PasswordStore != "" {
+		return nil
+	}
+	if err := setPassword(&fileConfig, fileConfig.User.Pass); err != nil {
+		fmt.Printf("  [WARN] The password is stored in plaintext: %v\n", err)
+		return nil
+	}
+	if err := writeConfig(fileConfig); err != nil {
+		return err
+	}
+	fmt.Printf("  Moved the password in config.toml to the %v.\n", fileConfig.User.PasswordStore)
+	return nil
+}
+
+// setPassword saves the password to the keyring when available, otherwise
+// to the encrypted file, and removes the plaintext password from config.
+func setPassword(config *Config, password string) error {
+	store := passwordStoreFile
+	if keyringAvailable() {
+		if err := keyringSet(password); err == nil {
+			store = passwordStoreKeyring
+		} else {
+			fmt.Printf("  [WARN] Could not use the keyring: %v\n", err)
+		}
+	}
+	if store == passwordStoreFile {
+		if err := passwordFileSet(password); err != nil {
+			return err
+		}
+	}
+	config.User.Pass = ""
+	config.User.PasswordStore = store
+	return nil
+}
+
+// keyringAvailable reports whether the command line tool of the OS keyring
+// is installed: secret-tool (Secret Service) or security (macOS Keychain).
+func keyringAvailable() bool {
+	name := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		name = "security"
+	} else if runtime.GOOS == "windows" {
+		return false
+	}
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func keyringGet() (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("  [ERR] Could not read the password from the keyring: %v", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keyringSet(password string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		if strings.ContainsAny(password, "\r\n") {
+			return fmt.Errorf("the password contains a newline")
+		}
+		// security takes the password only from an argument or the terminal.
+		// The command is written to its interactive mode on stdin, so that the
+		// password does not appear in the process list.
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %v -a %v -w %v\n",
+			keyringService, keyringAccount, securityQuote(password)))
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label=pz: paiza.jp", "service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(password)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	// security -i reports a failed command only on stderr.
+	if err != nil {
+		return fmt.Errorf("%v %v", err, strings.TrimSpace(stderr.String()))
+	} else if runtime.GOOS == "darwin" && stderr.Len() > 0 {
+		return fmt.Errorf("%v", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// securityQuote quotes s for the interactive mode of security.
+func securityQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// getPasswordFilePath returns the file encrypted with a passphrase by age.
+func getPasswordFilePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "password.age"), nil
+}
+
+func passwordFileGet() (string, error) {
+	p, err := getPasswordFilePath()
+	if err != nil {
+		return "", err
+	}
+	// age asks the passphrase on the terminal.
+	cmd := exec.Command("age", "--decrypt", p)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("  [ERR] Could not decrypt %v: %v", p, err)
+	}
+	return string(out), nil
+}
+
+func passwordFileSet(password string) error {
+	if _, err := exec.LookPath("age"); err != nil {
+		return fmt.Errorf("  [ERR] Install secret-tool (or security on macOS) or age to save the password, or set passwordCommand / passwordEnv")
+	}
+	p, err := getPasswordFilePath()
+	if err != nil {
+		return err
+	}
+	fmt.Println("  Enter a passphrase to encrypt the password.")
+	cmd := exec.Command("age", "--passphrase", "--output", p)
+	cmd.Stdin = strings.NewReader(password)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("  [ERR] Could not encrypt the password: %v", err)
+	}
+	return os.Chmod(p, 0600)
+}
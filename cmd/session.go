@@ -0,0 +1,161 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sclevine/agouti"
+)
+
+const (
+	topURL    = "https://paiza.jp/"
+	signInURL = "https://paiza.jp/sign_in"
+)
+
+// getCookiePath returns the file which keeps the session cookies between
+// runs. It is readable only by the user, since the cookies work like a
+// password.
+func getCookiePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "cookies.json"), nil
+}
+
+// readCookies reads the cookie file. A corrupt file is removed with a
+// warning, so that the session falls back to the sign in form.
+func readCookies(cookiePath string) ([]*http.Cookie, error) {
+	if !Exists(cookiePath) {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(cookiePath)
+	if err != nil {
+		return nil, err
+	}
+	var cookies []*http.Cookie
+	if err := json.Unmarshal(b, &cookies); err != nil {
+		fmt.Fprintf(os.Stderr, "  [WARN] Ignored the corrupt cookie file %v: %v\n", cookiePath, err)
+		if err := os.Remove(cookiePath); err != nil {
+			fmt.Fprintf(os.Stderr, "  [WARN] Could not remove %v: %v\n", cookiePath, err)
+		}
+		return nil, nil
+	}
+	return cookies, nil
+}
+
+// restoreSession sets the saved cookies and opens the sign in page. It
+// reports whether the session is still logged in.
+func restoreSession(p *agouti.Page) (bool, error) {
+	cookiePath, err := getCookiePath()
+	if err != nil {
+		return false, err
+	}
+	cookies, err := readCookies(cookiePath)
+	if err != nil {
+		return false, err
+	}
+	if len(cookies) > 0 {
+		// Cookies can only be set for the domain of the current page.
+		if err := p.Navigate(topURL); err != nil {
+			return false, err
+		}
+		now := time.Now()
+		for _, c := range cookies {
+			if !c.Expires.IsZero() && c.Expires.Before(now) {
+				continue
+			}
+			// A cookie the browser rejects only costs a login.
+			if err := p.SetCookie(c); err != nil {
+				fmt.Fprintf(os.Stderr, "  [WARN] Ignored the saved cookie %v: %v\n", c.Name, err)
+			}
+		}
+	}
+	if err := p.Navigate(signInURL); err != nil {
+		return false, err
+	}
+	return loggedIn(p)
+}
+
+// loggedIn reports whether the sign in page was skipped, which means the
+// session is valid.
+func loggedIn(p *agouti.Page) (bool, error) {
+	url, err := p.URL()
+	if err != nil {
+		return false, err
+	}
+	if !strings.Contains(url, "/sign_in") {
+		return true, nil
+	}
+	n, err := p.FindByID("email").Count()
+	if err != nil {
+		return false, err
+	}
+	return n == 0, nil
+}
+
+// waitLoggedIn waits for the page to leave the sign in form.
+func waitLoggedIn(p *agouti.Page, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		valid, err := loggedIn(p)
+		if err != nil || valid || time.Now().After(deadline) {
+			return valid, err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// saveSession writes the cookies of the page to the cookie file.
+func saveSession(p *agouti.Page) error {
+	cookies, err := p.GetCookies()
+	if err != nil {
+		return err
+	}
+	for _, c := range cookies {
+		// Session cookies come back with the Unix epoch as their expiry.
+		if c.Expires.Unix() <= 0 {
+			c.Expires = time.Time{}
+		}
+	}
+	b, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	cookiePath, err := getCookiePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cookiePath), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(cookiePath, b, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(cookiePath, 0600); err != nil {
+		return fmt.Errorf("  [ERR] Could not restrict the permission of %v: %v", cookiePath, err)
+	}
+	return nil
+}
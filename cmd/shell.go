@@ -0,0 +1,250 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// commandContext expands and runs the commands written in template.toml
+// (run, build, judge, ...) for a problem directory.
+//
+// The following placeholders are available in every command:
+//
+//	{file}     absolute path of the solution file
+//	{dir}      problem directory
+//	{basename} solution file name without its extension
+//	{binary}   {dir}/{basename} (with .exe on Windows)
+//	{tests}    tests directory
+//	{input}    input file of the test case (debugger only)
+type commandContext struct {
+	vars  map[string]string
+	dir   string
+	shell bool
+}
+
+func newCommandContext(tempConfig templateConfig, quesDir string) commandContext {
+	basename := strings.TrimSuffix(tempConfig.File, filepath.Ext(tempConfig.File))
+	binary := filepath.Join(quesDir, basename)
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	return commandContext{
+		vars: map[string]string{
+			"file":     filepath.Join(quesDir, tempConfig.File),
+			"dir":      quesDir,
+			"basename": basename,
+			"binary":   binary,
+			"tests":    filepath.Join(quesDir, "tests"),
+		},
+		dir:   quesDir,
+		shell: tempConfig.Shell,
+	}
+}
+
+// expand replaces the placeholders in s. When quote is true, s is a shell
+// script and each value is quoted for where it appears: a bare placeholder
+// is quoted as a whole, and one already inside quotes is only escaped.
+func (c commandContext) expand(s string, quote bool) string {
+	if !quote {
+		for k, v := range c.vars {
+			s = strings.Replace(s, "{"+k+"}", v, -1)
+		}
+		return s
+	}
+	windows := runtime.GOOS == "windows"
+	var b strings.Builder
+	var q rune
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r == '{' {
+			end := i + 1
+			for end < len(rs) && rs[end] != '}' {
+				end++
+			}
+			if v, ok := c.vars[string(rs[i+1:end])]; ok && end < len(rs) {
+				b.WriteString(quoteIn(v, q))
+				i = end
+				continue
+			}
+		}
+		switch {
+		case r == '\\' && q != '\'' && !windows && i+1 < len(rs):
+			// An escaped character does not open or close a quote.
+			b.WriteRune(r)
+			i++
+			r = rs[i]
+		case q == 0 && (r == '"' || r == '\'' && !windows):
+			q = r
+		case q == r:
+			q = 0
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quoteIn quotes s to be put in a shell script at a position inside the
+// quote q, or outside of quotes when q is 0.
+func quoteIn(s string, q rune) string {
+	switch {
+	case q == 0:
+		return shellQuote(s)
+	case runtime.GOOS == "windows":
+		return s
+	case q == '\'':
+		return strings.Replace(s, "'", `'\''`, -1)
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\\"$`", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// command builds a command which runs in the problem directory. In shell
+// mode the text is passed to the system shell, otherwise it is split into
+// words like a shell does without supporting pipes or redirections.
+func (c commandContext) command(cmdText string, args ...string) (*exec.Cmd, error) {
+	var cmd *exec.Cmd
+	if c.shell {
+		script := c.expand(cmdText, true)
+		for _, a := range args {
+			script += " " + shellQuote(a)
+		}
+		if strings.TrimSpace(script) == "" {
+			return nil, fmt.Errorf("  [ERR] Set a execution command to template.toml")
+		}
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", script)
+		} else {
+			cmd = exec.Command("sh", "-c", script)
+		}
+	} else {
+		words, err := splitWords(cmdText)
+		if err != nil {
+			return nil, err
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("  [ERR] Set a execution command to template.toml")
+		}
+		for i, w := range words {
+			words[i] = c.expand(w, false)
+		}
+		words = append(words, args...)
+		cmd = exec.Command(words[0], words[1:]...)
+	}
+	cmd.Dir = c.dir
+	return cmd, nil
+}
+
+// splitWords splits a command line into words. It handles single and
+// double quotes, backslash escapes and $VAR / ${VAR} expansion.
+func splitWords(s string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && quote == 0 && runtime.GOOS != "windows",
+			r == '\\' && quote == '"' && i+1 < len(rs) && strings.ContainsRune(`"\$`, rs[i+1]):
+			if i+1 < len(rs) {
+				i++
+				word.WriteRune(rs[i])
+			}
+			inWord = true
+		case r == '$':
+			name, n := envName(rs[i+1:])
+			if n == 0 {
+				word.WriteRune(r)
+			} else {
+				word.WriteString(os.Getenv(name))
+				i += n
+			}
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case strings.ContainsRune("|&;<>", r):
+			return nil, fmt.Errorf("  [ERR] '%c' needs a shell. Set shell = true in template.toml", r)
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("  [ERR] Unterminated quote in command: %v", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// envName reads a variable name after '$' and returns it with the number
+// of consumed runes.
+func envName(rs []rune) (string, int) {
+	if len(rs) > 0 && rs[0] == '{' {
+		for i := 1; i < len(rs); i++ {
+			if rs[i] == '}' {
+				return string(rs[1:i]), i + 1
+			}
+		}
+		return "", 0
+	}
+	n := 0
+	for n < len(rs) && (rs[n] == '_' || 'a' <= rs[n] && rs[n] <= 'z' || 'A' <= rs[n] && rs[n] <= 'Z' || n > 0 && '0' <= rs[n] && rs[n] <= '9') {
+		n++
+	}
+	return string(rs[:n]), n
+}
+
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
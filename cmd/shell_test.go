@@ -0,0 +1,103 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslash is not an escape on Windows")
+	}
+	os.Setenv("PZ_TEST_VAR", "v a r")
+	defer os.Unsetenv("PZ_TEST_VAR")
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{in: "", want: []string{}},
+		{in: "python3 main.py", want: []string{"python3", "main.py"}},
+		{in: "  a \t b\n c  ", want: []string{"a", "b", "c"}},
+		{in: `a "b c" 'd e'`, want: []string{"a", "b c", "d e"}},
+		{in: `a"b"'c'd`, want: []string{"abcd"}},
+		{in: `"" ''`, want: []string{"", ""}},
+		{in: `a\ b \"c\"`, want: []string{"a b", `"c"`}},
+		{in: `"a \"b\" \$c \d"`, want: []string{`a "b" $c \d`}},
+		{in: `'a \"b $HOME'`, want: []string{`a \"b $HOME`}},
+		{in: "$PZ_TEST_VAR ${PZ_TEST_VAR}x", want: []string{"v a r", "v a rx"}},
+		{in: `"$PZ_TEST_VAR"`, want: []string{"v a r"}},
+		{in: "a $ b", want: []string{"a", "$", "b"}},
+		{in: "g++ -o {binary} {file}", want: []string{"g++", "-o", "{binary}", "{file}"}},
+		{in: "a | b", err: true},
+		{in: "a > out", err: true},
+		{in: `"a | b"`, want: []string{"a | b"}},
+		{in: `"abc`, err: true},
+		{in: `'abc`, err: true},
+	}
+	for _, tt := range tests {
+		got, err := splitWords(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("splitWords(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitWords(%q) returned an error: %v", tt.in, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the shell quoting differs on Windows")
+	}
+	c := commandContext{vars: map[string]string{
+		"file":   "/tmp/my dir/main.py",
+		"dir":    "/tmp/it's",
+		"binary": `/tmp/a"$b`,
+	}}
+	tests := []struct {
+		in    string
+		quote bool
+		want  string
+	}{
+		{in: "python3 {file}", quote: false, want: "python3 /tmp/my dir/main.py"},
+		{in: "python3 {file}", quote: true, want: "python3 '/tmp/my dir/main.py'"},
+		{in: `python3 "{file}"`, quote: true, want: `python3 "/tmp/my dir/main.py"`},
+		{in: `python3 '{file}'`, quote: true, want: `python3 '/tmp/my dir/main.py'`},
+		{in: "cd {dir}", quote: true, want: `cd '/tmp/it'\''s'`},
+		{in: "cd '{dir}'", quote: true, want: `cd '/tmp/it'\''s'`},
+		{in: `cd "{dir}"`, quote: true, want: `cd "/tmp/it's"`},
+		{in: `"{binary}"`, quote: true, want: `"/tmp/a\"\$b"`},
+		{in: `echo \"{file}`, quote: true, want: `echo \"'/tmp/my dir/main.py'`},
+		{in: `echo "a'b" {file}`, quote: true, want: `echo "a'b" '/tmp/my dir/main.py'`},
+		{in: "{unknown} {file", quote: true, want: "{unknown} {file"},
+		{in: "{file}{file}", quote: true, want: "'/tmp/my dir/main.py''/tmp/my dir/main.py'"},
+	}
+	for _, tt := range tests {
+		if got := c.expand(tt.in, tt.quote); got != tt.want {
+			t.Errorf("expand(%q, %v) = %q, want %q", tt.in, tt.quote, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+const defaultStressCount = 100
+
+// stressOptions controls the stress test.
+type stressOptions struct {
+	count int
+	seed  int
+}
+
+var stressOpts stressOptions
+var stressTemp string
+
+// stressCmd represents the stress command
+var stressCmd = &cobra.Command{
+	Use:   "stress [dir|ID]",
+	Short: "Compare the solution with a brute-force one on random inputs",
+	Args:  cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		temp, err := resolveTemplate(stressTemp)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		quesDir, err := resolveQuesDir(strings.Join(args, ""))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		passed, err := stress(temp, quesDir, stressOpts)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// stress runs the generator, the reference solution and the solution with
+// increasing seeds until their outputs differ. The failing input is saved
+// as a custom case. It reports whether every input passed.
+func stress(temp, quesDir string, opts stressOptions) (bool, error) {
+	if temp == "" {
+		return false, fmt.Errorf("  [ERR] Select a template.")
+	}
+	runner, err := newTestRunner(temp, quesDir)
+	if err != nil {
+		return false, err
+	}
+	if runner.probConfig.Generator == "" || runner.probConfig.Reference == "" {
+		return false, fmt.Errorf("  [ERR] Set generator and reference to %v", filepath.Join(quesDir, "problem.toml"))
+	}
+	config, err := getConfig()
+	if err != nil {
+		return false, err
+	}
+	if opts.count <= 0 {
+		opts.count = defaultStressCount
+	}
+	built, err := buildProgram(runner.tempConfig, runner.cmdCtx, os.Stdout)
+	if err != nil {
+		return false, err
+	}
+	if !built {
+		return false, nil
+	}
+
+	workDir, err := ioutil.TempDir("", "pz-stress-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(workDir)
+	tc := testCase{
+		label:      "stress",
+		inputPath:  filepath.Join(workDir, "input.txt"),
+		outputPath: filepath.Join(workDir, "output.txt"),
+	}
+
+	for i := 0; i < opts.count; i++ {
+		seed := opts.seed + i
+		fmt.Printf("\r  Stress: %v/%v (seed:%v)", i+1, opts.count, seed)
+		input, err := runHelper(runner, "generator", runner.probConfig.Generator, "", strconv.Itoa(seed))
+		if err != nil {
+			fmt.Println()
+			return false, err
+		}
+		output, err := runHelper(runner, "reference", runner.probConfig.Reference, input)
+		if err != nil {
+			fmt.Println()
+			return false, err
+		}
+		if err = writeFile(tc.inputPath, input); err != nil {
+			return false, err
+		}
+		if err = writeFile(tc.outputPath, output); err != nil {
+			return false, err
+		}
+		cr := runner.runCase(tc)
+		if cr.err != nil {
+			fmt.Println()
+			return false, cr.err
+		}
+		if cr.verdict == verdictAC {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(ansi.Color(fmt.Sprintf("  [Stress] Found a failing input. (seed:%v)", seed), "red"))
+		runner.printCase(cr, config.Setting, os.Stdout)
+		n, err := saveCustomCase(quesDir, input, output)
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("  Saved as custom %v\n", n)
+		return false, nil
+	}
+	fmt.Println()
+	fmt.Println(ansi.Color(fmt.Sprintf("  [Stress] No difference in %v cases.", opts.count), "green"))
+	return true, nil
+}
+
+// runHelper runs the generator or the reference solution and returns its stdout.
+func runHelper(runner testRunner, name, cmdText, input string, args ...string) (string, error) {
+	cmd, err := runner.cmdCtx.command(cmdText, args...)
+	if err != nil {
+		return "", err
+	}
+	cmd.Stdin = bytes.NewBufferString(input)
+	result, err := runCommand(cmd, false, runner.timeout)
+	if err != nil {
+		return "", err
+	}
+	if result.timedOut {
+		return "", fmt.Errorf("  [ERR] The %v exceeded the time limit.", name)
+	}
+	if result.exitCode != 0 || result.signal != "" {
+		printRuntimeError("    ", result, os.Stdout)
+		return "", fmt.Errorf("  [ERR] The %v failed.", name)
+	}
+	return result.stdout, nil
+}
+
+func init() {
+	rootCmd.AddCommand(stressCmd)
+
+	stressCmd.Flags().StringVarP(&stressTemp, "template", "t", "", "template name (default: defaultTemplate)")
+	stressCmd.Flags().IntVarP(&stressOpts.count, "count", "n", defaultStressCount, "number of random inputs")
+	stressCmd.Flags().IntVar(&stressOpts.seed, "seed", 1, "first seed passed to the generator")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var testOpts testOptions
+var testTemp string
+var testWatch bool
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [dir|ID]",
+	Short: "Run the test cases without a browser",
+	Args:  cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		temp, err := resolveTemplate(testTemp)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		quesDir, err := resolveQuesDir(strings.Join(args, ""))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if testWatch {
+			if err := watch(temp, quesDir, testOpts); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+		passed, err := test(temp, quesDir, testOpts)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringVarP(&testTemp, "template", "t", "", "template name (default: defaultTemplate)")
+	testCmd.Flags().BoolVarP(&testWatch, "watch", "w", false, "rerun the tests when the files change")
+	addTestFlags(testCmd.Flags(), &testOpts)
+}
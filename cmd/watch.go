@@ -0,0 +1,128 @@
+/*
+Copyright © 2021 MagicalLiebe <magical.liebe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mgutz/ansi"
+)
+
+// watchDebounce is the quiet period after the last change before the
+// tests are rerun, so that a save made of several writes runs them once.
+const watchDebounce = 300 * time.Millisecond
+
+// watch reruns the tests whenever the solution file or the test cases
+// change, until Enter is pressed.
+func watch(temp, quesDir string, opts testOptions) error {
+	if temp == "" {
+		return fmt.Errorf("  [ERR] Select a template.")
+	}
+	tempConfig, _, err := getTemplateConfig(temp)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// Directories are watched instead of files because many editors save
+	// by renaming a temporary file.
+	testDir := filepath.Join(quesDir, "tests")
+	customDir := getCustomDir(quesDir)
+	if err = watchDirs(watcher, quesDir, testDir, customDir); err != nil {
+		return err
+	}
+	programPath := filepath.Join(quesDir, tempConfig.File)
+	problemPath := filepath.Join(quesDir, "problem.toml")
+
+	opts.compact = true
+	runTest := func() {
+		fmt.Println(ansi.Color(fmt.Sprintf("  [Watch] %v", time.Now().Format("15:04:05")), "cyan"))
+		if _, err := test(temp, quesDir, opts); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Println("  Watching for changes... (press Enter to stop)")
+	}
+	runTest()
+
+	stop := waitEnter()
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			dir := filepath.Dir(event.Name)
+			if event.Name == programPath || event.Name == problemPath || event.Name == testDir || dir == testDir || dir == customDir {
+				// tests and tests/custom may be created while watching, e.g.
+				// by the first addcase, which creates both at once.
+				if event.Op&fsnotify.Create != 0 && (event.Name == testDir || event.Name == customDir) {
+					if err := watchDirs(watcher, testDir, customDir); err != nil {
+						fmt.Println(err)
+					}
+				}
+				debounce = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println(err)
+		case <-debounce:
+			debounce = nil
+			runTest()
+		}
+	}
+}
+
+// watchDirs adds the directories which exist to the watcher.
+func watchDirs(watcher *fsnotify.Watcher, dirs ...string) error {
+	for _, dir := range dirs {
+		if f, err := os.Stat(dir); err == nil && f.IsDir() {
+			if err = watcher.Add(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// waitEnter returns a channel which is closed when Enter is pressed. It
+// reads os.Stdin byte by byte so that nothing after the line is consumed.
+func waitEnter() <-chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		b := make([]byte, 1)
+		for {
+			n, err := os.Stdin.Read(b)
+			if err != nil || (n == 1 && b[0] == '\n') {
+				return
+			}
+		}
+	}()
+	return stop
+}
@@ -6,8 +6,11 @@ require (
 	github.com/BurntSushi/toml v0.4.1
 	github.com/PuerkitoBio/goquery v1.7.1
 	github.com/atotto/clipboard v0.1.4
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
 	github.com/onsi/gomega v1.14.0 // indirect
 	github.com/sclevine/agouti v3.0.0+incompatible
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007
 )